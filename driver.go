@@ -3,9 +3,10 @@ package main
 import (
 	"context"
 	"errors"
-	"net/http"
-	"net/url"
+	"fmt"
+	"io"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/tidwall/gjson"
@@ -14,6 +15,9 @@ import (
 
 	"golang.org/x/sync/singleflight"
 
+	"openlist-lanzou-plugin/internal/core"
+	"openlist-lanzou-plugin/internal/lanzou"
+
 	_ "github.com/OpenListTeam/go-wasi-http/wasihttp"
 
 	openlistwasiplugindriver "github.com/OpenListTeam/openlist-wasi-plugin-driver"
@@ -28,6 +32,18 @@ func main() {
 
 }
 
+// 接入 OpenList 日志
+type pluginLogger struct{}
+
+func (pluginLogger) Debugf(format string, v ...any) { openlistwasiplugindriver.Debugf(format, v...) }
+func (pluginLogger) Infof(format string, v ...any)  { openlistwasiplugindriver.Infof(format, v...) }
+func (pluginLogger) Warnf(format string, v ...any)  { openlistwasiplugindriver.Warnf(format, v...) }
+func (pluginLogger) Errorf(format string, v ...any) { openlistwasiplugindriver.Errorf(format, v...) }
+
+func init() {
+	lanzou.SetLogger(pluginLogger{})
+}
+
 var DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/141.0.0.0 Safari/537.36 Edg/141.0.0.0"
 
 var _ openlistwasiplugindriver.Driver = (*LanZou)(nil)
@@ -36,20 +52,25 @@ var _ openlistwasiplugindriver.Move = (*LanZou)(nil)
 var _ openlistwasiplugindriver.Rename = (*LanZou)(nil)
 var _ openlistwasiplugindriver.Remove = (*LanZou)(nil)
 var _ openlistwasiplugindriver.Put = (*LanZou)(nil)
+var _ openlistwasiplugindriver.Copy = (*LanZou)(nil)
+var _ openlistwasiplugindriver.StreamReader = (*LanZou)(nil)
 
 type LanZou struct {
 	openlistwasiplugindriver.DriverHandle
 	Addition
 
-	CookieJar         *cookiejar.Jar
-	Client            *resty.Client
-	ClientNotRedirect *resty.Client
-	UploadClient      *resty.Client
+	api *lanzou.Client
+
+	listCache *core.ListCache
+	linkCache *core.LinkCache
+	limiter   *core.RateLimiter
 
-	uid string
-	vei string
+	sessionMu        sync.Mutex
+	sessionExpires   time.Time // 会话 cookie 过期时间,未知时为零值
+	sessionWarnLevel int
+	sessionCheckedAt time.Time // 上次在请求中检查过期时间
 
-	loginGroup singleflight.Group
+	linkGroup singleflight.Group
 }
 
 func (*LanZou) GetProperties() drivertypes.DriverProps {
@@ -79,12 +100,31 @@ func (*LanZou) GetFormMeta() []drivertypes.FormField {
 			Name:  "cookie",
 			Label: "Cookie",
 			Kind:  drivertypes.FieldKindPasswordKind(""),
-			Help:  "about 15 days valid, ignore if shareUrl is used",
+			Help:  "about 15 days valid, refreshed cookies are saved automatically, ignore if shareUrl is used",
+		},
+		{
+			Name:  "cookie_import",
+			Label: "Cookie Import",
+			Kind:  drivertypes.FieldKindTextKind(""),
+			Help:  "paste cookies.txt or a browser extension JSON export, moved into cookie on save and cleared",
+		},
+		{
+			Name:  "login_verify",
+			Label: "Login Verify",
+			Kind:  drivertypes.FieldKindStringKind(""),
+			Help:  "completed login verification as session_id|sig|scene|token[|formhash], only needed when login asks for verification, cleared after use",
+		},
+		{
+			Name:  "session",
+			Label: "Session",
+			Kind:  drivertypes.FieldKindPasswordKind(""),
+			Help:  "saved login session, filled automatically, clear it to force a new login in account mode",
 		},
 		{
 			Name:  "root_folder_id",
 			Label: "RootFolderId",
 			Kind:  drivertypes.FieldKindStringKind(""),
+			Help:  "folder id, or share id / full share link in url mode",
 		},
 
 		{
@@ -92,6 +132,18 @@ func (*LanZou) GetFormMeta() []drivertypes.FormField {
 			Label: "Share Password",
 			Kind:  drivertypes.FieldKindStringKind(""),
 		},
+		{
+			Name:  "shares",
+			Label: "Shares",
+			Kind:  drivertypes.FieldKindTextKind(""),
+			Help:  "mount several shares in url mode, one per line as share_url|password|name, password and name are optional",
+		},
+		{
+			Name:  "folder_passwords",
+			Label: "Folder Passwords",
+			Kind:  drivertypes.FieldKindTextKind(""),
+			Help:  "passwords of encrypted subfolders in url mode, one per line as share_id|password",
+		},
 		{
 			Name:     "base_url",
 			Label:    "Base URL",
@@ -118,6 +170,42 @@ func (*LanZou) GetFormMeta() []drivertypes.FormField {
 			Kind:  drivertypes.FieldKindBooleanKind(true),
 			Help:  "To use webdav, you need to enable it",
 		},
+		{
+			Name:  "base_rate_limit",
+			Label: "Base Rate Limit",
+			Kind:  drivertypes.FieldKindNumberKind(5),
+			Help:  "max requests per second to base url, 0 to only slow down when busy. up to 3 requests may start at once after idle",
+		},
+		{
+			Name:  "share_rate_limit",
+			Label: "Share Rate Limit",
+			Kind:  drivertypes.FieldKindNumberKind(1),
+			Help:  "max requests per second to share url, 0 to only slow down when busy. resolving a link takes about 3 requests, which may start at once after idle; later links wait 1/limit seconds per request, about 3s each at 1",
+		},
+		{
+			Name:  "list_cache_ttl",
+			Label: "List Cache TTL",
+			Kind:  drivertypes.FieldKindNumberKind(60),
+			Help:  "seconds to cache folder listings, 0 to disable",
+		},
+		{
+			Name:  "split_upload",
+			Label: "Split Upload",
+			Kind:  drivertypes.FieldKindBooleanKind(false),
+			Help:  "split files larger than split size into parts, only for account or cookie",
+		},
+		{
+			Name:  "split_size",
+			Label: "Split Size",
+			Kind:  drivertypes.FieldKindNumberKind(core.DefaultSplitSize),
+			Help:  "size of each part in MB, must stay below the account upload limit",
+		},
+		{
+			Name:  "wrap_ext",
+			Label: "Wrap Extension",
+			Kind:  drivertypes.FieldKindStringKind(""),
+			Help:  "upload file types refused by LanZou with this extension appended (e.g. zip), empty to disable",
+		},
 	}
 }
 
@@ -129,59 +217,25 @@ func (d *LanZou) Init(ctx context.Context) error {
 	if d.UserAgent == "" {
 		d.UserAgent = DefaultUserAgent
 	}
+	// 包装后缀本身必须允许上传
+	if ext := strings.TrimPrefix(d.WrapExt, "."); ext != "" && !lanzou.IsAllowedExt("."+ext) {
+		return fmt.Errorf("wrap_ext %q is not a file type LanZou accepts", d.WrapExt)
+	}
 
 	cookieJar, _ := cookiejar.New(nil)
 	createClient := func() *resty.Client {
-		return resty.New().SetHeaders(map[string]string{
+		return d.withSessionRefresh(d.withLimiter(resty.New().SetHeaders(map[string]string{
 			"Referer":    d.BaseUrl,
 			"User-Agent": d.UserAgent,
-		}).SetCookieJar(cookieJar)
+		}).SetCookieJar(cookieJar)))
 	}
 	createClient2 := func() *resty.Client {
-		client := createClient()
+		client := lanzou.WithChallenge(createClient(), cookieJar)
 
-		// 1. Add Conditions: Decide IF a retry is needed.
+		// 操作繁忙时重试
 		client.AddRetryConditions(
 			func(resp *resty.Response, err error) bool {
-				// If there's a transport error, don't retry unless it's a known temporary issue
-				if err != nil {
-					// You can add more specific error checks here if needed
-					return false
-				}
-
-				// Condition 1: Check for the anti-bot challenge
-				if strings.Contains(resp.String(), "acw_sc__v2") {
-					return true // Yes, we need to retry
-				}
-
-				// Condition 2: Check for "operation busy"
-				if gjson.GetBytes(resp.Bytes(), "zt").Int() == 4 {
-					return true // Yes, we need to retry
-				}
-
-				// For any other case, do not retry.
-				return false
-			},
-		)
-		// 2. Add Hooks: Perform actions BEFORE the next retry.
-		client.AddRetryHooks(
-			func(resp *resty.Response, err error) {
-				// This hook runs only if one of the conditions above returned true.
-
-				// If the response contains the anti-bot challenge...
-				if strings.Contains(resp.String(), "acw_sc__v2") {
-					// Calculate the required cookie value
-					vs, calcErr := CalcAcwScV2(resp.String())
-					if calcErr != nil {
-						// Log the error. The retry will still happen, but might fail again.
-						openlistwasiplugindriver.Warnf("lanzou: err => acw_sc__v2 validation error, data => %s\n", resp.String())
-						return
-					}
-
-					// Set the cookie for the NEXT request
-					u, _ := url.Parse(resp.Request.URL)
-					cookieJar.SetCookies(u, []*http.Cookie{{Name: "acw_sc__v2", Value: vs}})
-				}
+				return err == nil && gjson.GetBytes(resp.Bytes(), "zt").Int() == 4
 			},
 		)
 
@@ -190,26 +244,67 @@ func (d *LanZou) Init(ctx context.Context) error {
 			SetRetryMaxWaitTime(1 * time.Second)
 	}
 
-	d.CookieJar = cookieJar
-	d.Client = createClient2()
-	d.ClientNotRedirect = createClient2().SetRedirectPolicy(resty.NoRedirectPolicy())
-	d.UploadClient = createClient().SetRetryCount(0).SetTimeout(time.Minute * 2)
+	d.limiter = core.NewRateLimiter(map[string]float64{
+		core.BudgetBase:  d.BaseRateLimit,
+		core.BudgetShare: d.ShareRateLimit,
+	})
+	d.listCache = core.NewListCache(time.Duration(d.ListCacheTTL) * time.Second)
+	d.linkCache = core.NewLinkCache()
+	d.api = &lanzou.Client{
+		BaseUrl:           d.BaseUrl,
+		ShareUrl:          d.ShareUrl,
+		CookieJar:         cookieJar,
+		Client:            createClient2(),
+		ClientNotRedirect: createClient2().SetRedirectPolicy(resty.NoRedirectPolicy()),
+		UploadClient:      createClient().SetRetryCount(0).SetTimeout(time.Minute * 2),
+		FolderPasswords:   d.GetFolderPasswords(),
+		OnParamsChanged:   d.onParamsChanged,
+	}
 
 	switch d.Type {
 	case "account":
+		d.api.Relogin = d.relogin
+		if d.restoreSession() {
+			break
+		}
 		_, err := d.Login()
 		if err != nil {
 			return err
 		}
 	case "cookie":
-		cookies, err := cookiejar.ParseCookie(d.Cookie)
+		if err := d.importCookie(); err != nil {
+			return err
+		}
+		cookies, err := lanzou.ParseCookieConfig(d.Cookie)
 		if err != nil {
 			return err
 		}
-		if err := SetCookieToJar(cookieJar, d.BaseUrl, cookies); err != nil {
+		if err := lanzou.SetCookieToJar(cookieJar, d.BaseUrl, cookies); err != nil {
 			return err
 		}
+		d.restoreCookieExpiry(cookies)
 	default:
+		// 兼容直接粘贴的完整分享链接
+		host, shareID, pwd := lanzou.ParseShareLink(d.RootFolderID)
+		if d.RootFolderID != "" && shareID == "" {
+			return fmt.Errorf("no share id in share link %q", d.RootFolderID)
+		}
+		if err := d.checkShares(); err != nil {
+			return err
+		}
+		if shareID != "" {
+			d.RootFolderID = shareID
+		}
+		if host != "" {
+			d.ShareUrl = host
+			d.api.ShareUrl = host
+		}
+		if d.SharePassword == "" {
+			d.SharePassword = pwd
+		}
+		if len(d.GetShares()) == 0 && d.RootFolderID != "" {
+			return d.checkSharePassword()
+		}
 		return nil
 	}
 
@@ -217,53 +312,84 @@ func (d *LanZou) Init(ctx context.Context) error {
 		d.RootFolderID = "-1"
 	}
 
-	vei, uid, err := d.getVeiAndUid()
-	if err != nil {
-		return err
+	// 恢复的会话已获取 uid/vei
+	if _, uid := d.api.Params(); uid == "" {
+		if err := d.api.UpdateVeiAndUid(); err != nil {
+			return err
+		}
 	}
-	d.vei = vei
-	d.uid = uid
+	d.logSessionState()
 	return nil
 }
 
 func (d *LanZou) Drop(ctx context.Context) error {
-	d.uid = ""
-	d.vei = ""
-
-	d.CookieJar = nil
-	d.Client = nil
-	d.ClientNotRedirect = nil
-	d.UploadClient = nil
+	d.sessionExpires = time.Time{}
+	d.sessionWarnLevel = 0
+	d.sessionCheckedAt = time.Time{}
+
+	// 后台刷新可能仍持有旧的链接缓存
+	d.linkCache.Clear()
+	d.api = nil
+	d.listCache = nil
+	d.linkCache = nil
+	d.limiter = nil
 	return nil
 }
 
 func (d *LanZou) ListFiles(ctx context.Context, dir drivertypes.Object) ([]drivertypes.Object, error) {
+	if objs, ok := d.listCache.Get(dir.ID); ok {
+		return objs, nil
+	}
+
 	var objs []drivertypes.Object
 	var err error
 	if d.IsCookie() || d.IsAccount() {
 		objs, err = d.GetAllFiles(dir.ID)
 	} else {
-		objs, err = d.GetFileOrFolderByShareUrl(dir.ID, d.SharePassword)
+		// 多分享时根目录下每个分享作为一个文件夹
+		if shares := d.GetShares(); len(shares) > 0 && dir.ID == d.RootFolderID {
+			return lanzou.MustSliceConvert(shares, func(share core.ShareEntry) drivertypes.Object {
+				folder := lanzou.FileOrFolderByShareUrl{
+					ID:       share.ID,
+					NameAll:  share.Name,
+					IsFloder: true,
+					Pwd:      share.Pwd,
+				}
+				return core.ShareFileToObject(&folder)
+			}), nil
+		}
+
+		pwd := adapter.ExtraGetDefable(dir.Extra, "pwd")
+		if pwd == "" {
+			pwd = d.SharePassword
+		}
+		objs, err = d.GetFileOrFolderByShareUrl(dir.ID, pwd)
 	}
 
 	if err != nil {
 		return nil, err
 	}
+	d.listCache.Set(dir.ID, objs)
 	return objs, nil
 }
 
 func (d *LanZou) LinkFile(ctx context.Context, file drivertypes.Object, args drivertypes.LinkArgs) (*drivertypes.LinkResource, *drivertypes.Object, error) {
 	var (
 		err   error
-		dfile *FileOrFolderByShareUrl
+		dfile *lanzou.FileOrFolderByShareUrl
 	)
 	extra := adapter.ExtraToMap(file.Extra)
 	patch := false
 
 	switch adapter.ExtraGetDefable(file.Extra, "type") {
 	case "0":
+		// 分卷文件由 LinkRange 依次读取
+		if _, _, ok := core.GetSplitParts(file); ok {
+			link := drivertypes.LinkResourceRangeReader()
+			return &link, nil, nil
+		}
 		if extra["fid"] == "" {
-			sfile, err := d.getFileShareUrlByID(file.ID)
+			sfile, err := d.api.GetFileShareUrlByID(file.ID)
 			if err != nil {
 				return nil, nil, err
 			}
@@ -276,14 +402,14 @@ func (d *LanZou) LinkFile(ctx context.Context, file drivertypes.Object, args dri
 		return nil, nil, errors.New("file Information Lost")
 	}
 
-	dfile, err = d.GetFilesByShareUrl(extra["fid"], extra["pwd"])
+	dfile, err = d.GetDownloadFile(extra["fid"], extra["pwd"])
 	if err != nil {
 		return nil, nil, err
 	}
 
 	if d.RepairFileInfo {
 		if _, ok := extra["repair"]; !ok {
-			size, time := d.getFileRealInfo(dfile.Url)
+			size, time := d.api.GetFileRealInfo(dfile.Url)
 			file.Size = size
 			file.Created = drivertypes.Duration(time.UnixNano())
 			file.Modified = drivertypes.Duration(time.UnixNano())
@@ -296,7 +422,7 @@ func (d *LanZou) LinkFile(ctx context.Context, file drivertypes.Object, args dri
 		file.Extra = adapter.ExtraFormMap(extra)
 	}
 
-	exp := GetExpirationTime(dfile.Url)
+	exp := lanzou.GetExpirationTime(dfile.Url)
 	header := httptypes.NewFields()
 	header.Append("User-Agent", httptypes.FieldValue(cm.ToList([]byte(d.UserAgent))))
 	link := drivertypes.LinkResourceDirect(drivertypes.LinkInfo{
@@ -307,26 +433,26 @@ func (d *LanZou) LinkFile(ctx context.Context, file drivertypes.Object, args dri
 	return &link, &file, nil
 }
 
+func (d *LanZou) LinkRange(ctx context.Context, file drivertypes.Object, args drivertypes.LinkArgs, range_ drivertypes.RangeSpec, w io.WriteCloser) error {
+	defer w.Close()
+	return d.linkSplitRange(ctx, file, range_.Offset, range_.Size, w)
+}
+
 func (d *LanZou) MakeDir(ctx context.Context, parentDir drivertypes.Object, dirName string) (*drivertypes.Object, error) {
 	if d.IsCookie() || d.IsAccount() {
-		data, err := d.Doupload(func(req *resty.Request) {
-			req.SetContext(ctx)
-			req.SetFormData(map[string]string{
-				"task":               "2",
-				"parent_id":          parentDir.ID,
-				"folder_name":        dirName,
-				"folder_description": "",
-			})
-		}, nil)
+		defer d.listCache.Delete(parentDir.ID)
+
+		folderID, err := d.api.MakeDir(ctx, parentDir.ID, dirName, "")
 		if err != nil {
 			return nil, err
 		}
 
-		folder := FileOrFolder{
+		folder := lanzou.FileOrFolder{
 			Name:  dirName,
-			FolID: gjson.GetBytes(data, "text").String(),
+			FolID: folderID,
 		}
-		obj := folder.ToObject()
+		obj := core.FileToObject(&folder)
+		core.SetParent(&obj, parentDir.ID)
 		return &obj, nil
 	}
 	return nil, adapter.ErrNotSupport
@@ -334,63 +460,87 @@ func (d *LanZou) MakeDir(ctx context.Context, parentDir drivertypes.Object, dirN
 
 func (d *LanZou) Move(ctx context.Context, srcObj, dstDir drivertypes.Object) (*drivertypes.Object, error) {
 	if d.IsCookie() || d.IsAccount() {
-		if !srcObj.IsFolder {
-			_, err := d.Doupload(func(req *resty.Request) {
-				req.SetContext(ctx)
-				req.SetFormData(map[string]string{
-					"task":      "20",
-					"folder_id": dstDir.ID,
-					"file_id":   srcObj.ID,
-				})
-			}, nil)
-			if err != nil {
-				return nil, err
+		defer d.listCache.DeleteParent(srcObj)
+		defer d.listCache.Delete(dstDir.ID, srcObj.ID)
+
+		if srcObj.IsFolder {
+			return d.moveFolder(ctx, srcObj, dstDir)
+		}
+		if entries, ok := core.SplitFileEntries(srcObj, srcObj.Name); ok {
+			for _, entry := range entries {
+				if err := d.api.MoveFile(ctx, entry[0], dstDir.ID); err != nil {
+					return nil, err
+				}
 			}
+			core.SetParent(&srcObj, dstDir.ID)
 			return &srcObj, nil
 		}
+
+		if err := d.api.MoveFile(ctx, srcObj.ID, dstDir.ID); err != nil {
+			return nil, err
+		}
+		core.SetParent(&srcObj, dstDir.ID)
+		return &srcObj, nil
 	}
 	return nil, adapter.ErrNotSupport
 }
 
 func (d *LanZou) Rename(ctx context.Context, srcObj drivertypes.Object, newName string) (*drivertypes.Object, error) {
 	if d.IsCookie() || d.IsAccount() {
-		if !srcObj.IsFolder {
-			_, err := d.Doupload(func(req *resty.Request) {
-				req.SetContext(ctx)
-				req.SetFormData(map[string]string{
-					"task":      "46",
-					"file_id":   srcObj.ID,
-					"file_name": newName,
-					"type":      "2",
-				})
-			}, nil)
+		defer d.listCache.DeleteParent(srcObj)
+
+		if srcObj.IsFolder {
+			// 文件夹编辑会同时覆盖描述，先取回原描述避免被清空
+			share, err := d.api.GetFolderShareUrlByID(srcObj.ID)
 			if err != nil {
 				return nil, err
 			}
+			if err := d.api.EditFolder(ctx, srcObj.ID, newName, share.Des); err != nil {
+				return nil, err
+			}
 			srcObj.Name = newName
 			return &srcObj, nil
 		}
+
+		if entries, ok := core.SplitFileEntries(srcObj, newName); ok {
+			for _, entry := range entries {
+				if err := d.api.RenameFile(ctx, entry[0], entry[1]); err != nil {
+					return nil, err
+				}
+			}
+			srcObj.Name = newName
+			return &srcObj, nil
+		}
+
+		if err := d.api.RenameFile(ctx, srcObj.ID, lanzou.WrapName(newName, d.WrapExt)); err != nil {
+			return nil, err
+		}
+		srcObj.Name = newName
+		return &srcObj, nil
 	}
 	return nil, adapter.ErrNotSupport
 }
 
 func (d *LanZou) Remove(ctx context.Context, obj drivertypes.Object) error {
 	if d.IsCookie() || d.IsAccount() {
-		_, err := d.Doupload(func(req *resty.Request) {
-			req.SetContext(ctx)
-			if obj.IsFolder {
-				req.SetFormData(map[string]string{
-					"task":      "3",
-					"folder_id": obj.ID,
-				})
-			} else {
-				req.SetFormData(map[string]string{
-					"task":    "6",
-					"file_id": obj.ID,
-				})
+		defer d.listCache.DeleteParent(obj)
+		defer d.listCache.Delete(obj.ID)
+		// 链接缓存以分享ID为键,无法只删除该文件
+		defer d.linkCache.Clear()
+
+		if entries, ok := core.SplitFileEntries(obj, obj.Name); ok {
+			for _, entry := range entries {
+				if err := d.api.RemoveFile(ctx, entry[0]); err != nil {
+					return err
+				}
 			}
-		}, nil)
-		return err
+			return nil
+		}
+
+		if obj.IsFolder {
+			return d.api.RemoveFolder(ctx, obj.ID)
+		}
+		return d.api.RemoveFile(ctx, obj.ID)
 	}
 	return adapter.ErrNotSupport
 }
@@ -402,26 +552,45 @@ func (d *LanZou) Put(ctx context.Context, dstDir drivertypes.Object, file adapte
 			return nil, err
 		}
 		defer stream.Close()
+		defer d.listCache.Delete(dstDir.ID)
 
-		var resp RespText[[]FileOrFolder]
-		_, err = d.Request(http.MethodPost, MustUrlJoin(d.BaseUrl, "/html5up.php"), func(client *resty.Request) {
-			client.SetContext(ctx).
-				SetMultipartFormData(map[string]string{
-					"task":           "1",
-					"vie":            "2",
-					"ve":             "2",
-					"id":             "WU_FILE_0",
-					"name":           file.Object.Name,
-					"folder_id_bb_n": dstDir.ID,
-				}).
-				SetFileReader("upload_file", file.Object.Name, stream)
-		}, &resp, true)
+		var obj *drivertypes.Object
+		if d.SplitUpload && file.Object.Size > d.GetSplitSize() {
+			obj, err = d.putSplit(ctx, dstDir.ID, file.Object.Name, file.Object.Size, stream)
+		} else {
+			obj, err = d.upload(ctx, dstDir.ID, file.Object.Name, stream)
+		}
+		if err != nil {
+			return nil, err
+		}
+		core.SetParent(obj, dstDir.ID)
+		return obj, nil
+	}
+	return nil, adapter.ErrNotSupport
+}
 
+func (d *LanZou) Copy(ctx context.Context, srcObj, dstDir drivertypes.Object) (*drivertypes.Object, error) {
+	if d.IsCookie() || d.IsAccount() {
+		defer d.listCache.Delete(dstDir.ID)
+
+		var (
+			obj *drivertypes.Object
+			err error
+		)
+		if srcObj.IsFolder {
+			if err = d.api.CheckMoveTarget(srcObj.ID, dstDir.ID); err == nil {
+				obj, err = d.copyFolder(ctx, srcObj.ID, srcObj.Name, dstDir.ID)
+			}
+		} else if _, _, ok := core.GetSplitParts(srcObj); ok {
+			obj, err = d.copySplit(ctx, srcObj, dstDir.ID)
+		} else {
+			obj, err = d.copyFile(ctx, srcObj.ID, srcObj.Name, dstDir.ID)
+		}
 		if err != nil {
 			return nil, err
 		}
-		obj := resp.Text[0].ToObject()
-		return &obj, nil
+		core.SetParent(obj, dstDir.ID)
+		return obj, nil
 	}
 	return nil, adapter.ErrNotSupport
 }
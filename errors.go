@@ -0,0 +1,14 @@
+package main
+
+import (
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"github.com/OpenListTeam/openlist-wasi-plugin-driver/adapter"
+)
+
+// 蓝奏云错误分类对应的 adapter 错误,OpenList 据此显示状态
+func init() {
+	lanzou.AliasError(lanzou.ErrNotFound, adapter.ErrNotFound)
+	lanzou.AliasError(lanzou.ErrUnauthorized, adapter.ErrUnauthorized)
+	lanzou.AliasError(lanzou.ErrNotSupported, adapter.ErrNotSupport)
+}
@@ -1,13 +1,15 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"io"
 	"net/http"
-	"time"
 
-	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
-	"resty.dev/v3"
+	"openlist-lanzou-plugin/internal/core"
+	"openlist-lanzou-plugin/internal/lanzou"
 
-	"strconv"
+	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
 )
 
 /*
@@ -16,100 +18,111 @@ import (
 
 // 获取文件和文件夹,获取到的文件大小、更改时间不可信
 func (d *LanZou) GetAllFiles(folderID string) ([]drivertypes.Object, error) {
-	folders, err := d.GetFolders(folderID)
+	folders, err := d.api.GetFolders(folderID)
 	if err != nil {
 		return nil, err
 	}
-	files, err := d.GetFiles(folderID)
+	files, err := d.api.GetFiles(folderID)
 	if err != nil {
 		return nil, err
 	}
 
 	objs := make([]drivertypes.Object, 0, len(folders)+len(files))
 	for _, folder := range folders {
-		objs = append(objs, folder.ToObject())
+		objs = append(objs, core.FileToObject(&folder))
+	}
+	// 关闭分卷上传后已上传的分卷文件仍按原文件显示
+	splits, files := lanzou.GroupSplitFiles(files)
+	for _, split := range splits {
+		objs = append(objs, core.SplitFileToObject(&split))
 	}
 	for _, file := range files {
-		objs = append(objs, file.ToObject())
+		objs = append(objs, core.FileToObject(&file))
+	}
+	for i := range objs {
+		core.SetParent(&objs[i], folderID)
 	}
 	return objs, nil
 }
 
-// 通过ID获取文件夹
-func (d *LanZou) GetFolders(folderID string) ([]FileOrFolder, error) {
-	var resp RespText[[]FileOrFolder]
-	_, err := d.Doupload(func(req *resty.Request) {
-		req.SetFormData(map[string]string{
-			"task":      "47",
-			"folder_id": folderID,
-		})
-	}, &resp)
+// 移动文件夹,返回新文件夹
+func (d *LanZou) moveFolder(ctx context.Context, srcObj, dstDir drivertypes.Object) (*drivertypes.Object, error) {
+	newID, err := d.api.MoveFolder(ctx, srcObj.ID, srcObj.Name, dstDir.ID)
 	if err != nil {
 		return nil, err
 	}
-	return resp.Text, nil
+	folder := lanzou.FileOrFolder{Name: srcObj.Name, FolID: newID}
+	obj := core.FileToObject(&folder)
+	return &obj, nil
 }
 
-// 通过ID获取文件
-func (d *LanZou) GetFiles(folderID string) ([]FileOrFolder, error) {
-	files := make([]FileOrFolder, 0)
-	for pg := 1; ; pg++ {
-		var resp RespText[[]FileOrFolder]
-		_, err := d.Doupload(func(req *resty.Request) {
-			req.SetFormData(map[string]string{
-				"task":      "5",
-				"folder_id": folderID,
-				"pg":        strconv.Itoa(pg),
-			})
-		}, &resp)
-		if err != nil {
-			return nil, err
-		}
-		if len(resp.Text) == 0 {
-			break
-		}
-		files = append(files, resp.Text...)
+// 上传文件
+func (d *LanZou) upload(ctx context.Context, folderID, name string, reader io.Reader) (*drivertypes.Object, error) {
+	file, err := d.api.Upload(ctx, folderID, lanzou.WrapName(name, d.WrapExt), reader)
+	if err != nil {
+		return nil, err
 	}
-	return files, nil
+	obj := core.FileToObject(file)
+	return &obj, nil
 }
 
-// 通过ID获取文件夹分享地址
-func (d *LanZou) getFolderShareUrlByID(fileID string) (*FileShare, error) {
-	var resp RespInfo[FileShare]
-	_, err := d.Doupload(func(req *resty.Request) {
-		req.SetFormData(map[string]string{
-			"task":    "18",
-			"file_id": fileID,
-		})
-	}, &resp)
+// 通过ID获取文件下载链接
+func (d *LanZou) getFileDownloadUrl(fileID string) (string, error) {
+	share, err := d.api.GetFileShareUrlByID(fileID)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	dfile, err := d.GetDownloadFile(share.FID, share.Pwd)
+	if err != nil {
+		return "", err
 	}
-	return &resp.Info, nil
+	return dfile.Url, nil
 }
 
-// 通过ID获取文件分享地址
-func (d *LanZou) getFileShareUrlByID(fileID string) (*FileShare, error) {
-	var resp RespInfo[FileShare]
-	_, err := d.Doupload(func(req *resty.Request) {
-		req.SetFormData(map[string]string{
-			"task":    "22",
-			"file_id": fileID,
-		})
-	}, &resp)
+// 复制文件,蓝奏云没有复制接口,通过分享链接下载后重新上传
+func (d *LanZou) copyFile(ctx context.Context, fileID, name, folderID string) (*drivertypes.Object, error) {
+	downURL, err := d.getFileDownloadUrl(fileID)
 	if err != nil {
 		return nil, err
 	}
-	return &resp.Info, nil
+
+	resp, err := d.api.Client.R().SetContext(ctx).SetDoNotParseResponse(true).Get(downURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode() != http.StatusOK {
+		return nil, fmt.Errorf("copy file %s: download status %d", name, resp.StatusCode())
+	}
+
+	return d.upload(ctx, folderID, name, resp.Body)
 }
 
-// 通过下载头获取真实文件信息
-func (d *LanZou) getFileRealInfo(downURL string) (int64, time.Time) {
-	res, _ := d.Client.R().Head(downURL)
-	if res == nil {
-		return 0, time.Time{}
-	}
-	time, _ := http.ParseTime(res.Header().Get("Last-Modified"))
-	size, _ := strconv.ParseInt(res.Header().Get("Content-Length"), 10, 64)
-	return size, time
+// 复制文件夹,在目标位置重建目录后递归复制
+// 先列出源文件夹,新建的目录不会出现在列表中
+func (d *LanZou) copyFolder(ctx context.Context, srcID, name, dstID string) (*drivertypes.Object, error) {
+	files, err := d.api.GetFiles(srcID)
+	if err != nil {
+		return nil, err
+	}
+	folders, err := d.api.GetFolders(srcID)
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := d.MakeDir(ctx, drivertypes.Object{ID: dstID}, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if _, err := d.copyFile(ctx, file.GetID(), file.GetName(), dst.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, folder := range folders {
+		if _, err := d.copyFolder(ctx, folder.GetID(), folder.GetName(), dst.ID); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
 }
@@ -7,6 +7,7 @@ require (
 	github.com/OpenListTeam/openlist-wasi-plugin-driver v0.0.0-20251105181311-31306d50eadb
 	github.com/tidwall/gjson v1.18.0
 	go.bytecodealliance.org/cm v0.3.0
+	golang.org/x/net v0.46.0
 	golang.org/x/sync v0.17.0
 	resty.dev/v3 v3.0.0-beta.3
 )
@@ -0,0 +1,149 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"github.com/OpenListTeam/openlist-wasi-plugin-driver/adapter"
+	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
+)
+
+// 文件列表缓存,以文件夹ID为键
+type ListCache struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	items map[string]listCacheItem
+}
+
+type listCacheItem struct {
+	objs   []drivertypes.Object
+	expire time.Time
+}
+
+// ttl 小于等于0时不缓存
+func NewListCache(ttl time.Duration) *ListCache {
+	return &ListCache{
+		ttl:   ttl,
+		items: make(map[string]listCacheItem),
+	}
+}
+
+func (c *ListCache) Get(folderID string) ([]drivertypes.Object, bool) {
+	if c == nil || c.ttl <= 0 {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[folderID]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(item.expire) {
+		delete(c.items, folderID)
+		return nil, false
+	}
+	return item.objs, true
+}
+
+func (c *ListCache) Set(folderID string, objs []drivertypes.Object) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[folderID] = listCacheItem{
+		objs:   objs,
+		expire: time.Now().Add(c.ttl),
+	}
+}
+
+func (c *ListCache) Delete(folderIDs ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range folderIDs {
+		delete(c.items, id)
+	}
+}
+
+func (c *ListCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+}
+
+// 使对象所在文件夹的缓存失效,不知道所在文件夹时清空缓存
+func (c *ListCache) DeleteParent(obj drivertypes.Object) {
+	if parentID, ok := adapter.ExtraGet(obj.Extra, "parent"); ok {
+		c.Delete(parentID)
+		return
+	}
+	c.Clear()
+}
+
+// 记录对象所在文件夹,用于缓存失效
+func SetParent(obj *drivertypes.Object, parentID string) {
+	obj.Extra = adapter.ExtraAppend(obj.Extra, [2]string{"parent", parentID})
+}
+
+const (
+	// 剩余有效期低于此值的链接不再使用
+	linkExpireMargin = 30 * time.Second
+	// 剩余有效期低于此值时提前在后台刷新
+	linkRefreshAhead = 3 * time.Minute
+)
+
+// 下载链接缓存,以 fid+pwd 为键,按链接中的过期时间失效
+type LinkCache struct {
+	mu    sync.Mutex
+	items map[string]lanzou.FileOrFolderByShareUrl
+}
+
+func NewLinkCache() *LinkCache {
+	return &LinkCache{items: make(map[string]lanzou.FileOrFolderByShareUrl)}
+}
+
+// 返回缓存的文件及是否需要提前刷新
+func (c *LinkCache) Get(key string) (file *lanzou.FileOrFolderByShareUrl, refresh bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	exp := lanzou.GetExpirationTime(item.Url)
+	if exp < linkExpireMargin {
+		delete(c.items, key)
+		return nil, false
+	}
+	return &item, exp < linkRefreshAhead
+}
+
+// 没有过期时间的链接不缓存
+func (c *LinkCache) Set(key string, file *lanzou.FileOrFolderByShareUrl) {
+	if c == nil || lanzou.GetExpirationTime(file.Url) < linkExpireMargin {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = *file
+}
+
+func (c *LinkCache) Clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+}
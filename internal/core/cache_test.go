@@ -0,0 +1,101 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
+)
+
+func TestListCache(t *testing.T) {
+	c := NewListCache(time.Minute)
+	objs := []drivertypes.Object{{ID: "1", Name: "a.zip"}}
+	c.Set("10", objs)
+	c.Set("20", objs)
+	if got, ok := c.Get("10"); !ok || len(got) != 1 || got[0].ID != "1" {
+		t.Fatalf("Get = %v, %v", got, ok)
+	}
+
+	// 知道所在文件夹时只删除该文件夹
+	obj := drivertypes.Object{ID: "1"}
+	SetParent(&obj, "10")
+	c.DeleteParent(obj)
+	if _, ok := c.Get("10"); ok {
+		t.Fatal("parent folder still cached")
+	}
+	if _, ok := c.Get("20"); !ok {
+		t.Fatal("other folder evicted")
+	}
+
+	// 不知道所在文件夹时全部清空
+	c.DeleteParent(drivertypes.Object{ID: "1"})
+	if _, ok := c.Get("20"); ok {
+		t.Fatal("cache not cleared")
+	}
+}
+
+func TestListCacheExpire(t *testing.T) {
+	c := NewListCache(time.Millisecond)
+	c.Set("10", []drivertypes.Object{{ID: "1"}})
+	time.Sleep(5 * time.Millisecond)
+	if _, ok := c.Get("10"); ok {
+		t.Fatal("expired entry returned")
+	}
+
+	// ttl 为 0 时不缓存,nil 缓存可以直接使用
+	c = NewListCache(0)
+	c.Set("10", []drivertypes.Object{{ID: "1"}})
+	if _, ok := c.Get("10"); ok {
+		t.Fatal("cached with zero ttl")
+	}
+	var nilCache *ListCache
+	nilCache.Set("10", nil)
+	nilCache.Delete("10")
+	nilCache.Clear()
+	if _, ok := nilCache.Get("10"); ok {
+		t.Fatal("nil cache returned entry")
+	}
+}
+
+func linkFile(expire time.Duration) *lanzou.FileOrFolderByShareUrl {
+	return &lanzou.FileOrFolderByShareUrl{
+		Url: fmt.Sprintf("https://developer.lanzoug.com/file/?e=%d&sign=x", time.Now().Add(expire).Unix()),
+	}
+}
+
+func TestLinkCache(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    *lanzou.FileOrFolderByShareUrl
+		cached  bool
+		refresh bool
+	}{
+		{name: "fresh", file: linkFile(time.Hour), cached: true},
+		{name: "refresh ahead", file: linkFile(time.Minute), cached: true, refresh: true},
+		{name: "nearly expired", file: linkFile(10 * time.Second)},
+		{name: "no expiration", file: &lanzou.FileOrFolderByShareUrl{Url: "https://developer.lanzoug.com/file/?sign=x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewLinkCache()
+			c.Set("fid", tt.file)
+			file, refresh := c.Get("fid")
+			if (file != nil) != tt.cached || refresh != tt.refresh {
+				t.Fatalf("Get = %v, %v, want cached %v, refresh %v", file, refresh, tt.cached, tt.refresh)
+			}
+			if file != nil && file.Url != tt.file.Url {
+				t.Fatalf("url = %s", file.Url)
+			}
+		})
+	}
+
+	c := NewLinkCache()
+	c.Set("fid", linkFile(time.Hour))
+	c.Clear()
+	if file, _ := c.Get("fid"); file != nil {
+		t.Fatal("cache not cleared")
+	}
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+)
+
+/*
+解析配置中的多行文本
+*/
+
+// 分享链接配置
+type ShareEntry struct {
+	ID   string
+	Pwd  string
+	Name string
+}
+
+// 解析多分享配置,每行一个: 分享链接|提取码|显示名称
+func ParseShares(config string) []ShareEntry {
+	var shares []ShareEntry
+	for _, line := range strings.Split(config, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "|")
+		if fields[0] == "" {
+			continue
+		}
+		for len(fields) < 3 {
+			fields = append(fields, "")
+		}
+
+		// 分享统一通过 ShareUrl 访问,忽略链接中的域名
+		_, id, pwd := lanzou.ParseShareLink(fields[0])
+		share := ShareEntry{
+			ID:   id,
+			Pwd:  strings.TrimSpace(fields[1]),
+			Name: strings.TrimSpace(fields[2]),
+		}
+		if share.ID == "" {
+			continue
+		}
+		if share.Pwd == "" {
+			share.Pwd = pwd
+		}
+		if share.Name == "" {
+			share.Name = share.ID
+		}
+		shares = append(shares, share)
+	}
+	return shares
+}
+
+// 检查多分享配置中的链接都能识别出分享ID
+func CheckShares(config string) error {
+	for _, line := range strings.Split(config, "\n") {
+		link, _, _ := strings.Cut(strings.TrimSpace(line), "|")
+		if link == "" {
+			continue
+		}
+		if _, id, _ := lanzou.ParseShareLink(link); id == "" {
+			return fmt.Errorf("no share id in share link %q", link)
+		}
+	}
+	return nil
+}
+
+// 解析子文件夹提取码配置,每行一个: 分享ID或链接|提取码
+func ParseFolderPasswords(config string) map[string]string {
+	passwords := make(map[string]string)
+	for _, line := range strings.Split(config, "\n") {
+		fields := strings.SplitN(strings.TrimSpace(line), "|", 2)
+		_, id, pwd := lanzou.ParseShareLink(fields[0])
+		if len(fields) == 2 {
+			pwd = strings.TrimSpace(fields[1])
+		}
+		if id != "" && pwd != "" {
+			passwords[id] = pwd
+		}
+	}
+	return passwords
+}
+
+// 解析登录验证配置: sessionId|sig|scene|token|formhash,formhash 可省略
+func ParseLoginVerify(config string) (lanzou.LoginVerify, bool) {
+	fields := strings.Split(strings.TrimSpace(config), "|")
+	if len(fields) < 4 {
+		return lanzou.LoginVerify{}, false
+	}
+	for len(fields) < 5 {
+		fields = append(fields, "")
+	}
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return lanzou.LoginVerify{
+		SessionId: fields[0],
+		Sig:       fields[1],
+		Scene:     fields[2],
+		Token:     fields[3],
+		Formhash:  fields[4],
+	}, true
+}
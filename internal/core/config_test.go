@@ -0,0 +1,75 @@
+package core
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+)
+
+func TestParseShares(t *testing.T) {
+	config := `https://wwop.lanzoul.com/b0abc?pwd=x7q2
+  iAbc123 | 1234 | 资料
+https://x.lanzoux.com/b0def|ab12
+链接：|1234
+
+b0ghi||`
+	want := []ShareEntry{
+		{ID: "b0abc", Pwd: "x7q2", Name: "b0abc"},
+		{ID: "iAbc123", Pwd: "1234", Name: "资料"},
+		{ID: "b0def", Pwd: "ab12", Name: "b0def"},
+		{ID: "b0ghi", Name: "b0ghi"},
+	}
+	if got := ParseShares(config); !slices.Equal(got, want) {
+		t.Fatalf("ParseShares = %+v", got)
+	}
+	if got := ParseShares(""); len(got) != 0 {
+		t.Fatalf("empty config = %+v", got)
+	}
+
+	// 配置的提取码优先于链接中的提取码
+	if got := ParseShares("https://wwop.lanzoul.com/b0abc?pwd=x7q2|pw"); got[0].Pwd != "pw" {
+		t.Fatalf("pwd = %s", got[0].Pwd)
+	}
+}
+
+func TestCheckShares(t *testing.T) {
+	if err := CheckShares("b0abc|1234\n\nhttps://x.lanzoux.com/b0def"); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckShares("b0abc\n链接：|1234"); err == nil {
+		t.Fatal("bad share link accepted")
+	}
+}
+
+func TestParseFolderPasswords(t *testing.T) {
+	config := `b0abc|1234
+https://x.lanzoux.com/b0def?pwd=ab12
+b0ghi 密码:5678
+b0jkl|
+|9999`
+	want := map[string]string{"b0abc": "1234", "b0def": "ab12", "b0ghi": "5678"}
+	if got := ParseFolderPasswords(config); !maps.Equal(got, want) {
+		t.Fatalf("ParseFolderPasswords = %v", got)
+	}
+}
+
+func TestParseLoginVerify(t *testing.T) {
+	tests := []struct {
+		config string
+		want   lanzou.LoginVerify
+		ok     bool
+	}{
+		{config: "sid|sig|scene|token|hash", want: lanzou.LoginVerify{SessionId: "sid", Sig: "sig", Scene: "scene", Token: "token", Formhash: "hash"}, ok: true},
+		{config: " sid | sig | scene | token ", want: lanzou.LoginVerify{SessionId: "sid", Sig: "sig", Scene: "scene", Token: "token"}, ok: true},
+		{config: "sid|sig|scene"},
+		{config: ""},
+	}
+	for _, tt := range tests {
+		got, ok := ParseLoginVerify(tt.config)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseLoginVerify(%q) = %+v, %v", tt.config, got, ok)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"time"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"github.com/tidwall/gjson"
+)
+
+/*
+自适应限速
+按 BaseUrl 和 ShareUrl 分别限制请求间隔,遇到操作繁忙或反爬验证时加倍间隔,请求成功后逐步恢复
+空闲后允许连续发出 limitBurst 个请求,一次链接解析需要约 3 个请求,单个下载不会被间隔拖慢
+*/
+
+const (
+	BudgetBase  = "base"
+	BudgetShare = "share"
+
+	limitBackoffMin = 500 * time.Millisecond
+	limitBackoffMax = 10 * time.Second
+	limitRecoverMin = 50 * time.Millisecond
+
+	// 空闲后可以不等待连续发出的请求数
+	limitBurst = 3
+)
+
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+}
+
+type rateBucket struct {
+	min      time.Duration // 配置的最小间隔
+	interval time.Duration // 当前间隔
+	next     time.Time     // 下一个请求可以发出的时间
+}
+
+// limits 为每秒请求数,小于等于0时只在繁忙时限速
+func NewRateLimiter(limits map[string]float64) *RateLimiter {
+	l := &RateLimiter{buckets: make(map[string]*rateBucket, len(limits))}
+	for name, limit := range limits {
+		var interval time.Duration
+		if limit > 0 {
+			interval = time.Duration(float64(time.Second) / limit)
+		}
+		l.buckets[name] = &rateBucket{min: interval, interval: interval}
+	}
+	return l
+}
+
+// 等待直到可以发出请求
+func (l *RateLimiter) Wait(ctx context.Context, name string) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	b, ok := l.buckets[name]
+	if !ok {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	// 空闲时最多积攒 limitBurst 个请求的额度
+	if earliest := now.Add(-b.interval * (limitBurst - 1)); b.next.Before(earliest) {
+		b.next = earliest
+	}
+	wait := b.next.Sub(now)
+	b.next = b.next.Add(b.interval)
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// 根据响应调整间隔,繁忙时返回加倍后的间隔
+func (l *RateLimiter) Observe(name string, busy bool) (backoff time.Duration) {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, ok := l.buckets[name]
+	if !ok {
+		return 0
+	}
+	if busy {
+		b.interval = min(max(b.interval*2, limitBackoffMin), limitBackoffMax)
+		// 繁忙时清空积攒的额度
+		if now := time.Now(); b.next.Before(now) {
+			b.next = now
+		}
+		return b.interval
+	}
+	if b.interval > b.min {
+		b.interval -= b.interval / 8
+		if b.interval < max(b.min, limitRecoverMin) {
+			b.interval = b.min
+		}
+	}
+	return 0
+}
+
+// 判断是否为操作繁忙或反爬验证的响应
+func IsBusyResponse(data []byte) bool {
+	return lanzou.DetectChallenge(string(data)) != nil || gjson.GetBytes(data, "zt").Int() == 4
+}
+
+// 根据请求地址选择限速分组,非蓝奏云地址不限速
+func LimitBudget(rawURL, baseURL, shareURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	if base, err := url.Parse(baseURL); err == nil && base.Host == u.Host {
+		return BudgetBase
+	}
+	if share, err := url.Parse(shareURL); err == nil && share.Host == u.Host {
+		return BudgetShare
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	l := NewRateLimiter(map[string]float64{BudgetBase: 50, BudgetShare: 0})
+	ctx := context.Background()
+
+	// 空闲后前 limitBurst 个请求不等待
+	start := time.Now()
+	for i := 0; i < limitBurst; i++ {
+		if err := l.Wait(ctx, BudgetBase); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 15*time.Millisecond {
+		t.Fatalf("burst of %d requests took %s", limitBurst, elapsed)
+	}
+	// 之后按 50 rps 的间隔发出
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(ctx, BudgetBase); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 55*time.Millisecond {
+		t.Fatalf("%d requests at 50 rps took %s", limitBurst+3, elapsed)
+	}
+
+	// 不限速和未知分组不等待
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		l.Wait(ctx, BudgetShare)
+		l.Wait(ctx, "")
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Fatalf("unlimited requests took %s", elapsed)
+	}
+}
+
+func TestRateLimiterCancel(t *testing.T) {
+	l := NewRateLimiter(map[string]float64{BudgetBase: 0.1})
+	for i := 0; i < limitBurst; i++ {
+		l.Wait(context.Background(), BudgetBase)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, BudgetBase); err != context.DeadlineExceeded {
+		t.Fatalf("Wait = %v", err)
+	}
+}
+
+func TestRateLimiterObserve(t *testing.T) {
+	l := NewRateLimiter(map[string]float64{BudgetBase: 10})
+	b := l.buckets[BudgetBase]
+
+	// 繁忙时加倍,有上下限
+	if got := l.Observe(BudgetBase, true); got != limitBackoffMin {
+		t.Fatalf("first backoff = %s", got)
+	}
+	if got := l.Observe(BudgetBase, true); got != 2*limitBackoffMin {
+		t.Fatalf("second backoff = %s", got)
+	}
+	for i := 0; i < 10; i++ {
+		l.Observe(BudgetBase, true)
+	}
+	if b.interval != limitBackoffMax {
+		t.Fatalf("interval = %s, want %s", b.interval, limitBackoffMax)
+	}
+
+	// 成功后逐步恢复到配置的间隔
+	if got := l.Observe(BudgetBase, false); got != 0 || b.interval >= limitBackoffMax {
+		t.Fatalf("recover = %s, interval %s", got, b.interval)
+	}
+	for i := 0; i < 100; i++ {
+		l.Observe(BudgetBase, false)
+	}
+	if b.interval != b.min || b.min != 100*time.Millisecond {
+		t.Fatalf("interval = %s, min %s", b.interval, b.min)
+	}
+
+	if got := l.Observe("", true); got != 0 {
+		t.Fatalf("unknown budget backoff = %s", got)
+	}
+	var nilLimiter *RateLimiter
+	if nilLimiter.Wait(context.Background(), BudgetBase) != nil || nilLimiter.Observe(BudgetBase, true) != 0 {
+		t.Fatal("nil limiter limited")
+	}
+}
+
+func TestIsBusyResponse(t *testing.T) {
+	challenge, err := os.ReadFile("../lanzou/testdata/challenge/acw_sc__v2.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data string
+		busy bool
+	}{
+		{name: "busy", data: `{"zt":4,"info":"","text":null}`, busy: true},
+		{name: "challenge", data: string(challenge), busy: true},
+		{name: "ok", data: `{"zt":1,"info":"success","text":[]}`},
+		{name: "error", data: `{"zt":0,"info":"文件取消分享了"}`},
+		{name: "page", data: `<html><body>ok</body></html>`},
+	}
+	for _, tt := range tests {
+		if got := IsBusyResponse([]byte(tt.data)); got != tt.busy {
+			t.Errorf("%s: IsBusyResponse = %v", tt.name, got)
+		}
+	}
+}
+
+func TestLimitBudget(t *testing.T) {
+	const base, share = "https://pc.woozooo.com", "https://www.lanzoui.com"
+	tests := map[string]string{
+		"https://pc.woozooo.com/doupload.php":      BudgetBase,
+		"https://www.lanzoui.com/ajaxm.php?file=1": BudgetShare,
+		"https://developer.lanzoug.com/file/?e=1":  "",
+		"://bad": "",
+	}
+	for rawURL, want := range tests {
+		if got := LimitBudget(rawURL, base, share); got != want {
+			t.Errorf("LimitBudget(%s) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
+
+func TestRateLimiterBusyBurst(t *testing.T) {
+	l := NewRateLimiter(map[string]float64{BudgetShare: 1})
+	time.Sleep(time.Millisecond)
+	// 繁忙后不再使用积攒的额度
+	l.Observe(BudgetShare, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	l.Wait(ctx, BudgetShare)
+	if err := l.Wait(ctx, BudgetShare); err != context.DeadlineExceeded {
+		t.Fatalf("second request after busy = %v", err)
+	}
+}
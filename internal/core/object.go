@@ -0,0 +1,58 @@
+package core
+
+import (
+	"strconv"
+	"strings"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"github.com/OpenListTeam/openlist-wasi-plugin-driver/adapter"
+	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
+)
+
+/*
+插件中不依赖 SDK 运行时的逻辑,可以在本机直接 go test
+接口数据转换为 OpenList 对象
+*/
+
+func FileToObject(f *lanzou.FileOrFolder) drivertypes.Object {
+	return drivertypes.Object{
+		ID:       f.GetID(),
+		Name:     f.GetName(),
+		Size:     f.GetSize(),
+		IsFolder: f.IsDir(),
+		Modified: drivertypes.Duration(f.ModTime().UnixNano()),
+		Created:  drivertypes.Duration(f.CreateTime().UnixNano()),
+		Extra: adapter.ExtraFormMap(map[string]string{
+			"type": "0",
+		}),
+	}
+}
+
+func ShareFileToObject(f *lanzou.FileOrFolderByShareUrl) drivertypes.Object {
+	return drivertypes.Object{
+		ID:       f.GetID(),
+		Name:     f.GetName(),
+		Size:     f.GetSize(),
+		IsFolder: f.IsDir(),
+		Modified: drivertypes.Duration(f.ModTime().UnixNano()),
+		Created:  drivertypes.Duration(f.CreateTime().UnixNano()),
+		Extra: adapter.ExtraFormMap(map[string]string{
+			"type": "1",
+			"fid":  f.ID,
+			"pwd":  f.Pwd,
+		}),
+	}
+}
+
+func SplitFileToObject(f *lanzou.SplitFile) drivertypes.Object {
+	obj := FileToObject(&f.Manifest)
+	obj.Name = f.Name
+	obj.Size = f.Size
+	obj.Extra = adapter.ExtraFormMap(map[string]string{
+		"type":      "0",
+		"part_size": strconv.FormatInt(f.PartSize, 10),
+		"parts":     strings.Join(f.PartIDs, ","),
+	})
+	return obj
+}
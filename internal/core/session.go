@@ -0,0 +1,76 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+)
+
+/*
+会话持久化
+登录后保存的 cookie 和 uid/vei,以及会话 cookie 的过期提示
+*/
+
+type Session struct {
+	Account string `json:"account"`
+	Cookie  string `json:"cookie"`
+	Uid     string `json:"uid"`
+	Vei     string `json:"vei"`
+	Expires int64  `json:"expires,omitempty"` // 会话 cookie 过期时间戳
+}
+
+// 解析配置中保存的会话
+func ParseSession(data string) (session Session, ok bool) {
+	if data == "" || json.Unmarshal([]byte(data), &session) != nil {
+		return session, false
+	}
+	return session, true
+}
+
+func (s Session) String() string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+// 登录状态相关的 cookie
+var SessionCookieNames = map[string]bool{
+	"phpdisk_info": true,
+	"ylogin":       true,
+}
+
+// 会话 cookie 中最早的过期时间,没有时返回零值
+func SessionExpiry(cookies []*http.Cookie) time.Time {
+	var expires time.Time
+	for _, cookie := range cookies {
+		if e := lanzou.CookieExpiry(cookie); SessionCookieNames[cookie.Name] && !e.IsZero() && (expires.IsZero() || e.Before(expires)) {
+			expires = e
+		}
+	}
+	return expires
+}
+
+// 过期警告等级,剩余时间小于对应值时提示
+var SessionWarnLevels = []time.Duration{7 * lanzou.DAY, 3 * lanzou.DAY, lanzou.DAY, 0}
+
+// 剩余时间对应的警告等级,0 表示无需提示,len(SessionWarnLevels) 表示已过期
+func SessionWarnLevel(remaining time.Duration) int {
+	level := 0
+	for i, limit := range SessionWarnLevels {
+		if remaining < limit {
+			level = i + 1
+		}
+	}
+	return level
+}
+
+// 会话 cookie 已过期时返回的错误,属于 ErrUnauthorized,OpenList 会显示在存储状态中
+func SessionExpiredError(expires time.Time) error {
+	if expires.IsZero() || time.Until(expires) > 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: session cookie expired at %s (%d days ago), please update the cookie",
+		lanzou.ErrCookieExpiration, expires.Format(time.DateTime), int(time.Since(expires)/lanzou.DAY))
+}
@@ -0,0 +1,92 @@
+package core
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"github.com/OpenListTeam/openlist-wasi-plugin-driver/adapter"
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	session := Session{
+		Account: "13800000000",
+		Cookie:  "phpdisk_info=abc; ylogin=123",
+		Uid:     "123",
+		Vei:     "vei",
+		Expires: 1760000000,
+	}
+	got, ok := ParseSession(session.String())
+	if !ok || got != session {
+		t.Fatalf("ParseSession = %+v, %v", got, ok)
+	}
+
+	// 没有过期时间时不写入
+	if s := (Session{Account: "a"}).String(); s != `{"account":"a","cookie":"","uid":"","vei":""}` {
+		t.Fatalf("String = %s", s)
+	}
+
+	for _, data := range []string{"", "{", "[]"} {
+		if _, ok := ParseSession(data); ok {
+			t.Errorf("ParseSession(%q) ok", data)
+		}
+	}
+}
+
+func TestSessionExpiry(t *testing.T) {
+	now := time.Now()
+	cookies := []*http.Cookie{
+		{Name: "other", Value: "x", Expires: now.Add(time.Hour)},
+		{Name: "phpdisk_info", Value: "x", Expires: now.Add(48 * time.Hour)},
+		{Name: "ylogin", Value: "1", MaxAge: 86400},
+		{Name: "ylogin", Value: "1"},
+	}
+	// 只看会话 cookie,取最早的过期时间
+	got := SessionExpiry(cookies)
+	if d := got.Sub(now); d < 23*time.Hour || d > 25*time.Hour {
+		t.Fatalf("expiry in %s", d)
+	}
+	if got := SessionExpiry(cookies[:1]); !got.IsZero() {
+		t.Fatalf("expiry from other cookie = %s", got)
+	}
+}
+
+func TestSessionWarnLevel(t *testing.T) {
+	tests := []struct {
+		remaining time.Duration
+		level     int
+	}{
+		{30 * lanzou.DAY, 0},
+		{6 * lanzou.DAY, 1},
+		{2 * lanzou.DAY, 2},
+		{time.Hour, 3},
+		{-time.Hour, len(SessionWarnLevels)},
+	}
+	for _, tt := range tests {
+		if got := SessionWarnLevel(tt.remaining); got != tt.level {
+			t.Errorf("SessionWarnLevel(%s) = %d, want %d", tt.remaining, got, tt.level)
+		}
+	}
+}
+
+func TestSessionExpiredError(t *testing.T) {
+	lanzou.AliasError(lanzou.ErrUnauthorized, adapter.ErrUnauthorized)
+
+	if err := SessionExpiredError(time.Time{}); err != nil {
+		t.Fatalf("unknown expiry: %v", err)
+	}
+	if err := SessionExpiredError(time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("valid session: %v", err)
+	}
+	err := SessionExpiredError(time.Now().Add(-3*lanzou.DAY - time.Hour))
+	if !errors.Is(err, lanzou.ErrCookieExpiration) || !errors.Is(err, adapter.ErrUnauthorized) {
+		t.Fatalf("expired session: %v", err)
+	}
+	if !strings.Contains(err.Error(), "3 days ago") {
+		t.Fatalf("message = %s", err)
+	}
+}
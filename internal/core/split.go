@@ -0,0 +1,153 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"github.com/OpenListTeam/openlist-wasi-plugin-driver/adapter"
+	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
+)
+
+/*
+分卷上传
+超过单文件大小限制的文件被切分为多个分卷,另附一个清单文件
+清单文件名中记录了原文件大小和分卷大小,列表时无需下载清单即可还原
+*/
+
+// 默认分卷大小,单位MB,低于蓝奏云单文件 100MB 的限制
+const DefaultSplitSize = 90
+
+// 分卷大小,mb 为配置值,返回字节数
+func SplitSize(mb int64) int64 {
+	if mb <= 0 {
+		return DefaultSplitSize << 20
+	}
+	return mb << 20
+}
+
+// 上传单个文件,name 为上传后的文件名
+type UploadFunc func(ctx context.Context, name string, reader io.Reader) (*drivertypes.Object, error)
+
+// 分卷上传,失败时通过 remove 删除已上传的分卷
+func PutSplit(ctx context.Context, name string, size, partSize int64, reader io.Reader, upload UploadFunc, remove func(ctx context.Context, fileID string) error) (*drivertypes.Object, error) {
+	count := int((size + partSize - 1) / partSize)
+
+	manifest := lanzou.SplitManifest{
+		Name:     name,
+		Size:     size,
+		PartSize: partSize,
+		Parts:    make([]string, 0, count),
+	}
+	partIDs := make([]string, 0, count)
+	rollback := func(err error) error {
+		for _, id := range partIDs {
+			if rerr := remove(context.WithoutCancel(ctx), id); rerr != nil {
+				return errors.Join(err, rerr)
+			}
+		}
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		partName := lanzou.SplitPartName(name, i)
+		obj, err := upload(ctx, partName, io.LimitReader(reader, partSize))
+		if err != nil {
+			return nil, rollback(fmt.Errorf("upload part %s: %w", partName, err))
+		}
+		partIDs = append(partIDs, obj.ID)
+		manifest.Parts = append(manifest.Parts, partName)
+	}
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, rollback(err)
+	}
+	obj, err := upload(ctx, lanzou.SplitManifestName(name, size, partSize), bytes.NewReader(data))
+	if err != nil {
+		return nil, rollback(fmt.Errorf("upload split manifest: %w", err))
+	}
+
+	file := lanzou.SplitFile{
+		Manifest: lanzou.FileOrFolder{ID: obj.ID, NameAll: obj.Name},
+		Name:     name,
+		Size:     size,
+		PartSize: partSize,
+		PartIDs:  partIDs,
+	}
+	res := SplitFileToObject(&file)
+	return &res, nil
+}
+
+// 获取分卷文件的分卷信息,普通文件返回false
+func GetSplitParts(obj drivertypes.Object) (partIDs []string, partSize int64, ok bool) {
+	extra := adapter.ExtraToMap(obj.Extra)
+	if extra["parts"] == "" {
+		return nil, 0, false
+	}
+	partSize, err := strconv.ParseInt(extra["part_size"], 10, 64)
+	if err != nil || partSize <= 0 {
+		return nil, 0, false
+	}
+	return strings.Split(extra["parts"], ","), partSize, true
+}
+
+// 分卷内需要读取的范围[Start,End)
+type PartRange struct {
+	Index      int
+	ID         string
+	Start, End int64
+}
+
+// 计算读取分卷文件指定范围时每个分卷需要读取的部分
+// length 为 0 表示读到结尾
+func SplitRanges(obj drivertypes.Object, offset, length uint64) ([]PartRange, bool) {
+	partIDs, partSize, ok := GetSplitParts(obj)
+	if !ok {
+		return nil, false
+	}
+	if offset >= uint64(obj.Size) {
+		return nil, true
+	}
+	// 与剩余长度比较避免 offset+length 溢出
+	start, end := int64(offset), obj.Size
+	if length != 0 && length < uint64(obj.Size)-offset {
+		end = start + int64(length)
+	}
+	var ranges []PartRange
+	for i, id := range partIDs {
+		partStart := int64(i) * partSize
+		partEnd := min(partStart+partSize, obj.Size)
+		if partEnd <= start || partStart >= end {
+			continue
+		}
+		ranges = append(ranges, PartRange{
+			Index: i,
+			ID:    id,
+			Start: max(start, partStart) - partStart,
+			End:   min(end, partEnd) - partStart,
+		})
+	}
+	return ranges, true
+}
+
+// 分卷文件对应的实际文件[ID,名称],名称按 name 生成,清单在最后
+func SplitFileEntries(obj drivertypes.Object, name string) ([][2]string, bool) {
+	partIDs, partSize, ok := GetSplitParts(obj)
+	if !ok {
+		return nil, false
+	}
+	entries := make([][2]string, 0, len(partIDs)+1)
+	for i, id := range partIDs {
+		entries = append(entries, [2]string{id, lanzou.SplitPartName(name, i)})
+	}
+	entries = append(entries, [2]string{obj.ID, lanzou.SplitManifestName(name, obj.Size, partSize)})
+	return entries, true
+}
@@ -0,0 +1,190 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"testing"
+
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"github.com/OpenListTeam/openlist-wasi-plugin-driver/adapter"
+	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
+)
+
+// 记录上传内容的网盘,第 failAt 次上传失败
+type splitStore struct {
+	files   map[string][]byte
+	names   map[string]string
+	uploads int
+	failAt  int
+}
+
+func newSplitStore() *splitStore {
+	return &splitStore{files: map[string][]byte{}, names: map[string]string{}}
+}
+
+func (s *splitStore) upload(ctx context.Context, name string, reader io.Reader) (*drivertypes.Object, error) {
+	s.uploads++
+	if s.uploads == s.failAt {
+		return nil, errors.New("upload failed")
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	id := strconv.Itoa(s.uploads)
+	s.files[id] = data
+	s.names[id] = name
+	return &drivertypes.Object{ID: id, Name: name, Size: int64(len(data))}, nil
+}
+
+func (s *splitStore) remove(ctx context.Context, fileID string) error {
+	delete(s.files, fileID)
+	delete(s.names, fileID)
+	return nil
+}
+
+func TestPutSplit(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 25)
+	s := newSplitStore()
+	obj, err := PutSplit(context.Background(), "big.iso", int64(len(data)), 100, bytes.NewReader(data), s.upload, s.remove)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Name != "big.iso" || obj.Size != 250 || obj.ID != "4" {
+		t.Fatalf("object = %+v", obj)
+	}
+	partIDs, partSize, ok := GetSplitParts(*obj)
+	if !ok || partSize != 100 || !slices.Equal(partIDs, []string{"1", "2", "3"}) {
+		t.Fatalf("parts = %v, %d, %v", partIDs, partSize, ok)
+	}
+
+	var joined []byte
+	for i, id := range partIDs {
+		if s.names[id] != lanzou.SplitPartName("big.iso", i) {
+			t.Fatalf("part %d name = %s", i, s.names[id])
+		}
+		joined = append(joined, s.files[id]...)
+	}
+	if !bytes.Equal(joined, data) {
+		t.Fatal("parts do not add up to the original data")
+	}
+
+	if s.names[obj.ID] != lanzou.SplitManifestName("big.iso", 250, 100) {
+		t.Fatalf("manifest name = %s", s.names[obj.ID])
+	}
+	var manifest lanzou.SplitManifest
+	if err := json.Unmarshal(s.files[obj.ID], &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Size != 250 || manifest.PartSize != 100 || len(manifest.Parts) != 3 {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+
+	// 上传的文件能被列表合并回原文件
+	files := make([]lanzou.FileOrFolder, 0, len(s.names))
+	for id, name := range s.names {
+		files = append(files, lanzou.FileOrFolder{ID: id, NameAll: name})
+	}
+	splits, rest := lanzou.GroupSplitFiles(files)
+	if len(splits) != 1 || len(rest) != 0 || splits[0].Size != 250 || !slices.Equal(splits[0].PartIDs, partIDs) {
+		t.Fatalf("grouped = %+v, rest %v", splits, rest)
+	}
+}
+
+func TestPutSplitRollback(t *testing.T) {
+	// 分卷和清单上传失败时都删除已上传的分卷
+	for _, failAt := range []int{2, 4} {
+		s := newSplitStore()
+		s.failAt = failAt
+		data := bytes.Repeat([]byte("x"), 250)
+		if _, err := PutSplit(context.Background(), "big.iso", 250, 100, bytes.NewReader(data), s.upload, s.remove); err == nil {
+			t.Fatalf("fail at %d: no error", failAt)
+		}
+		if len(s.files) != 0 {
+			t.Fatalf("fail at %d: files left %v", failAt, s.names)
+		}
+	}
+}
+
+func splitObject(size, partSize int64, parts ...string) drivertypes.Object {
+	return SplitFileToObject(&lanzou.SplitFile{
+		Manifest: lanzou.FileOrFolder{ID: "m"},
+		Name:     "big.iso",
+		Size:     size,
+		PartSize: partSize,
+		PartIDs:  parts,
+	})
+}
+
+func TestSplitRanges(t *testing.T) {
+	obj := splitObject(250, 100, "a", "b", "c")
+	tests := []struct {
+		name           string
+		offset, length uint64
+		want           []PartRange
+	}{
+		{name: "all", want: []PartRange{{0, "a", 0, 100}, {1, "b", 0, 100}, {2, "c", 0, 50}}},
+		{name: "inside part", offset: 10, length: 20, want: []PartRange{{0, "a", 10, 30}}},
+		{name: "across parts", offset: 90, length: 120, want: []PartRange{{0, "a", 90, 100}, {1, "b", 0, 100}, {2, "c", 0, 10}}},
+		{name: "part boundary", offset: 100, length: 100, want: []PartRange{{1, "b", 0, 100}}},
+		{name: "tail", offset: 240, want: []PartRange{{2, "c", 40, 50}}},
+		{name: "past end", offset: 240, length: 100, want: []PartRange{{2, "c", 40, 50}}},
+		{name: "overflow", offset: 1, length: ^uint64(0), want: []PartRange{{0, "a", 1, 100}, {1, "b", 0, 100}, {2, "c", 0, 50}}},
+		{name: "offset at end", offset: 250},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := SplitRanges(obj, tt.offset, tt.length)
+			if !ok || !slices.Equal(got, tt.want) {
+				t.Fatalf("SplitRanges = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+
+	if _, ok := SplitRanges(drivertypes.Object{Size: 10}, 0, 0); ok {
+		t.Fatal("plain file has split ranges")
+	}
+}
+
+func TestSplitFileEntries(t *testing.T) {
+	obj := splitObject(250, 100, "a", "b", "c")
+	entries, ok := SplitFileEntries(obj, "new.iso")
+	want := [][2]string{
+		{"a", "new.iso.001.lzpart.zip"},
+		{"b", "new.iso.002.lzpart.zip"},
+		{"c", "new.iso.003.lzpart.zip"},
+		{"m", "new.iso.250-100.lzsplit.txt"},
+	}
+	if !ok || !slices.Equal(entries, want) {
+		t.Fatalf("entries = %v", entries)
+	}
+
+	// 分卷信息缺失或损坏时按普通文件处理
+	for _, extra := range []map[string]string{
+		{"type": "0"},
+		{"type": "0", "parts": "a,b", "part_size": "x"},
+		{"type": "0", "parts": "a,b", "part_size": "0"},
+	} {
+		if _, ok := SplitFileEntries(drivertypes.Object{Extra: adapter.ExtraFormMap(extra)}, "a"); ok {
+			t.Fatalf("extra %v treated as split file", extra)
+		}
+	}
+}
+
+func TestSplitSize(t *testing.T) {
+	if got := SplitSize(0); got != DefaultSplitSize<<20 {
+		t.Fatalf("default = %d", got)
+	}
+	if got := SplitSize(-1); got != DefaultSplitSize<<20 {
+		t.Fatalf("negative = %d", got)
+	}
+	if got := SplitSize(50); got != 50<<20 {
+		t.Fatalf("50 = %d", got)
+	}
+}
@@ -0,0 +1,293 @@
+package fixture
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+请求录制与回放
+录制真实的请求和响应,清理后保存为 testdata 下的固定文件,测试时由本地服务按顺序回放
+每个用例一个目录: fixture.json 记录请求列表和期望结果,响应内容单独保存,便于查看和修改
+响应中出现的蓝奏云地址统一替换为 {{host}},回放时替换为本地服务地址
+*/
+
+const (
+	Version     = 1
+	HostMark    = "{{host}}"
+	fixtureFile = "fixture.json"
+)
+
+type Fixture struct {
+	Version   int         `json:"version"`
+	Note      string      `json:"note,omitempty"`
+	Synthetic bool        `json:"synthetic,omitempty"` // 手工编写,不是录制的真实响应
+	ShareID   string      `json:"share_id"`
+	Pwd       string      `json:"pwd,omitempty"`
+	Exchanges []Exchange  `json:"exchanges"`
+	Want      []ShareFile `json:"want"` // 期望的解析结果
+}
+
+// 分享链接解析出的文件或文件夹
+type ShareFile struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Size  string `json:"size,omitempty"`
+	Time  string `json:"time,omitempty"`
+	Url   string `json:"url,omitempty"`
+	Pwd   string `json:"pwd,omitempty"`
+	IsDir bool   `json:"is_dir,omitempty"`
+}
+
+// 一次请求和响应,Path 包含查询参数
+type Exchange struct {
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Form   map[string]string `json:"form,omitempty"`
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"` // 响应内容的文件名
+	body   []byte
+}
+
+// 记录的响应头,其余响应头与解析无关,cookie 不保存
+var recordHeaders = []string{"Content-Type", "Location"}
+
+func Load(dir string) (*Fixture, error) {
+	data, err := os.ReadFile(filepath.Join(dir, fixtureFile))
+	if err != nil {
+		return nil, err
+	}
+	var f Fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("%s: unsupported fixture version %d", dir, f.Version)
+	}
+	for i := range f.Exchanges {
+		ex := &f.Exchanges[i]
+		if ex.Body == "" {
+			continue
+		}
+		if ex.body, err = os.ReadFile(filepath.Join(dir, ex.Body)); err != nil {
+			return nil, err
+		}
+	}
+	return &f, nil
+}
+
+func (f *Fixture) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f.Version = Version
+	for i := range f.Exchanges {
+		ex := &f.Exchanges[i]
+		if len(ex.body) == 0 {
+			ex.Body = ""
+			continue
+		}
+		ex.Body = fmt.Sprintf("%02d-%s%s", i+1, strings.ToLower(ex.Method), bodyExt(ex.Header["Content-Type"], ex.body))
+		if err := os.WriteFile(filepath.Join(dir, ex.Body), ex.body, 0o644); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, fixtureFile), append(data, '\n'), 0o644)
+}
+
+func bodyExt(contentType string, body []byte) string {
+	switch {
+	case strings.Contains(contentType, "json") || json.Valid(body):
+		return ".json"
+	case strings.Contains(contentType, "html") || bytes.Contains(body, []byte("<")):
+		return ".html"
+	}
+	return ".txt"
+}
+
+// 列出目录下的用例
+func List(root string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(root, "*", fixtureFile))
+	if err != nil {
+		return nil, err
+	}
+	dirs := make([]string, 0, len(matches))
+	for _, m := range matches {
+		dirs = append(dirs, filepath.Dir(m))
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// 录制请求的 RoundTripper
+// Sanitize 在保存前处理响应内容、路径和响应头,用于去除账号等隐私信息
+type Recorder struct {
+	Transport http.RoundTripper
+	Sanitize  func(string) string
+
+	mu        sync.Mutex
+	hosts     map[string]bool
+	exchanges []Exchange
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	ex := Exchange{Method: req.Method, Path: req.URL.RequestURI()}
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			if form, err := url.ParseQuery(string(data)); err == nil && strings.Contains(req.Header.Get("Content-Type"), "form-urlencoded") {
+				ex.Form = make(map[string]string, len(form))
+				for k := range form {
+					ex.Form[k] = form.Get(k)
+				}
+			}
+		}
+	}
+
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	ex.Status = resp.StatusCode
+	ex.body = body
+	for _, h := range recordHeaders {
+		if v := resp.Header.Get(h); v != "" {
+			if ex.Header == nil {
+				ex.Header = make(map[string]string)
+			}
+			ex.Header[h] = v
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hosts == nil {
+		r.hosts = make(map[string]bool)
+	}
+	r.hosts[req.URL.Scheme+"://"+req.URL.Host] = true
+	r.exchanges = append(r.exchanges, ex)
+	return resp, nil
+}
+
+// 清理录制内容,请求过的域名替换为 HostMark
+func (r *Recorder) Clean(s string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clean(s)
+}
+
+func (r *Recorder) clean(s string) string {
+	for host := range r.hosts {
+		s = strings.ReplaceAll(s, host, HostMark)
+		// json 中的地址会转义斜杠
+		s = strings.ReplaceAll(s, strings.ReplaceAll(host, "/", `\/`), HostMark)
+	}
+	if r.Sanitize != nil {
+		s = r.Sanitize(s)
+	}
+	return s
+}
+
+// 返回清理后的记录
+func (r *Recorder) Exchanges() []Exchange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	exchanges := make([]Exchange, len(r.exchanges))
+	for i, ex := range r.exchanges {
+		ex.Path = r.clean(ex.Path)
+		ex.body = []byte(r.clean(string(ex.body)))
+		header := make(map[string]string, len(ex.Header))
+		for k, v := range ex.Header {
+			header[k] = r.clean(v)
+		}
+		ex.Header = header
+		if ex.Form != nil {
+			form := make(map[string]string, len(ex.Form))
+			for k, v := range ex.Form {
+				form[k] = r.clean(v)
+			}
+			ex.Form = form
+		}
+		exchanges[i] = ex
+	}
+	return exchanges
+}
+
+// 按顺序回放记录的响应
+// 请求的方法、路径和表单必须与记录一致,不一致时通过 Errorf 报告
+type Replayer struct {
+	Fixture *Fixture
+	Host    string // 本地服务地址,替换 HostMark
+	Errorf  func(format string, args ...any)
+
+	mu   sync.Mutex
+	next int
+}
+
+func (p *Replayer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.next >= len(p.Fixture.Exchanges) {
+		p.Errorf("unexpected request %s %s after %d recorded exchanges", req.Method, req.URL.RequestURI(), p.next)
+		http.Error(w, "no more recorded exchanges", http.StatusNotImplemented)
+		return
+	}
+	ex := p.Fixture.Exchanges[p.next]
+	p.next++
+
+	if req.Method != ex.Method || req.URL.RequestURI() != ex.Path {
+		p.Errorf("exchange %d: got %s %s, recorded %s %s", p.next, req.Method, req.URL.RequestURI(), ex.Method, ex.Path)
+	}
+	if len(ex.Form) > 0 {
+		req.ParseForm()
+		for k, v := range ex.Form {
+			if got := req.PostForm.Get(k); got != v {
+				p.Errorf("exchange %d: form %s = %q, recorded %q", p.next, k, got, v)
+			}
+		}
+		for k := range req.PostForm {
+			if _, ok := ex.Form[k]; !ok {
+				p.Errorf("exchange %d: unexpected form field %s", p.next, k)
+			}
+		}
+	}
+
+	for k, v := range ex.Header {
+		w.Header().Set(k, strings.ReplaceAll(v, HostMark, p.Host))
+	}
+	w.WriteHeader(ex.Status)
+	w.Write([]byte(strings.ReplaceAll(string(ex.body), HostMark, p.Host)))
+}
+
+// 未回放的记录数
+func (p *Replayer) Remaining() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.Fixture.Exchanges) - p.next
+}
@@ -0,0 +1,240 @@
+package lanzou
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"resty.dev/v3"
+)
+
+/*
+反爬验证
+在页面被过多访问或其他情况下,有时候会先返回一个验证页面,计算出 cookie 或参数后重新访问才能获得正常页面
+每种验证实现一个 ChallengeSolver 并注册,客户端重试时依次尝试匹配的验证,直到不再返回验证页面
+*/
+
+// 验证结果,Cookies 写入 cookie jar,Params 作为查询参数附加到重试请求
+type ChallengeResult struct {
+	Cookies []*http.Cookie
+	Params  map[string]string
+}
+
+type ChallengeSolver interface {
+	// 验证名称,用于日志
+	Name() string
+	// 判断响应是否为该验证
+	Detect(body string) bool
+	// 计算验证结果
+	Solve(body string) (*ChallengeResult, error)
+}
+
+var (
+	challengeMu      sync.RWMutex
+	challengeSolvers []ChallengeSolver
+)
+
+// 注册验证,先注册的优先匹配
+func RegisterChallengeSolver(solver ChallengeSolver) {
+	challengeMu.Lock()
+	defer challengeMu.Unlock()
+	challengeSolvers = append(challengeSolvers, solver)
+}
+
+// 查找匹配响应的所有验证,按注册顺序排列
+func DetectChallenges(body string) []ChallengeSolver {
+	challengeMu.RLock()
+	defer challengeMu.RUnlock()
+	var solvers []ChallengeSolver
+	for _, solver := range challengeSolvers {
+		if solver.Detect(body) {
+			solvers = append(solvers, solver)
+		}
+	}
+	return solvers
+}
+
+// 查找匹配响应的验证,没有匹配时返回 nil
+func DetectChallenge(body string) ChallengeSolver {
+	if solvers := DetectChallenges(body); len(solvers) > 0 {
+		return solvers[0]
+	}
+	return nil
+}
+
+// 计算验证结果,跳过 tried 中已经尝试过的验证,尝试过的验证会记录到 tried
+func SolveChallenge(body string, tried map[string]bool) (ChallengeSolver, *ChallengeResult, error) {
+	var errs []error
+	for _, solver := range DetectChallenges(body) {
+		if tried[solver.Name()] {
+			continue
+		}
+		tried[solver.Name()] = true
+		result, err := solver.Solve(body)
+		if err == nil {
+			return solver, result, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", solver.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, nil, errors.New("no challenge solver left")
+	}
+	return nil, nil, errors.Join(errs...)
+}
+
+// 响应是否为验证页面且还有未尝试的验证
+func hasUntriedChallenge(req *resty.Request, body string) bool {
+	solvers := DetectChallenges(body)
+	if len(solvers) == 0 {
+		return false
+	}
+	tried := challengeTried(req)
+	for _, solver := range solvers {
+		if !tried[solver.Name()] {
+			return true
+		}
+	}
+	return false
+}
+
+type challengeTriedKey struct{}
+
+// 请求中已经尝试过的验证
+// 使用验证结果重试后仍返回验证页面,说明结果被拒绝,下次重试换下一个验证
+func challengeTried(req *resty.Request) map[string]bool {
+	tried, ok := req.Context().Value(challengeTriedKey{}).(map[string]bool)
+	if !ok {
+		tried = make(map[string]bool)
+		req.SetContext(context.WithValue(req.Context(), challengeTriedKey{}, tried))
+	}
+	return tried
+}
+
+func init() {
+	RegisterChallengeSolver(DefaultAcwScV2Solver)
+}
+
+// 为客户端添加验证处理,遇到验证页面时计算结果后重试
+// 结果被拒绝时依次尝试其他匹配的验证,都尝试过后不再重试
+func WithChallenge(client *resty.Client, jar http.CookieJar) *resty.Client {
+	return client.
+		AddRetryConditions(func(resp *resty.Response, err error) bool {
+			return err == nil && hasUntriedChallenge(resp.Request, resp.String())
+		}).
+		AddRetryHooks(func(resp *resty.Response, err error) {
+			if err != nil || resp == nil {
+				return
+			}
+			body := resp.String()
+			tried := challengeTried(resp.Request)
+			if len(tried) > 0 {
+				log.Warnf("lanzou: challenge result rejected by %s, trying next solver\n", resp.Request.URL)
+			}
+			solver, result, err := SolveChallenge(body, tried)
+			if err != nil {
+				// 重试仍会进行,但可能再次失败
+				log.Warnf("lanzou: err => challenge validation error: %v, data => %s\n", err, body)
+				return
+			}
+			log.Debugf("lanzou: %s challenge solved\n", solver.Name())
+
+			if u, err := url.Parse(resp.Request.URL); err == nil && len(result.Cookies) > 0 {
+				jar.SetCookies(u, result.Cookies)
+				// 重试共用请求头,上次写入的 Cookie 头仍在,删除后由 resty 和 cookie jar 重新写入
+				resp.Request.Header.Del("Cookie")
+			}
+			for k, v := range result.Params {
+				resp.Request.SetQueryParam(k, v)
+			}
+		})
+}
+
+// acw_sc__v2 验证
+// 页面通过 arg1 打乱顺序后与 mask 异或得到 cookie 值,Box 和 Mask 变化时注册新的实例即可
+type AcwScV2Solver struct {
+	Box  []int
+	Mask string
+}
+
+var DefaultAcwScV2Solver = &AcwScV2Solver{
+	Box:  []int{6, 28, 34, 31, 33, 18, 30, 23, 9, 8, 19, 38, 17, 24, 0, 5, 32, 21, 10, 22, 25, 14, 15, 3, 16, 27, 13, 35, 2, 29, 11, 26, 4, 36, 1, 39, 37, 7, 20, 12},
+	Mask: "3000176000856006061501533003690027800375",
+}
+
+var findAcwScV2Reg = regexp.MustCompile(`arg1\s*=\s*'([0-9A-Fa-f]+)'`)
+
+func (s *AcwScV2Solver) Name() string { return "acw_sc__v2" }
+
+// 只匹配 arg1 长度与 Box 一致的页面,其他版本的页面交给对应的实例处理
+func (s *AcwScV2Solver) Detect(body string) bool {
+	if !strings.Contains(body, "acw_sc__v2") {
+		return false
+	}
+	matches := findAcwScV2Reg.FindStringSubmatch(body)
+	return len(matches) == 2 && len(matches[1]) == len(s.Box)
+}
+
+func (s *AcwScV2Solver) Solve(body string) (*ChallengeResult, error) {
+	value, err := s.Calc(body)
+	if err != nil {
+		return nil, err
+	}
+	return &ChallengeResult{Cookies: []*http.Cookie{{Name: "acw_sc__v2", Value: value}}}, nil
+}
+
+// 计算 acw_sc__v2
+func (s *AcwScV2Solver) Calc(htmlContent string) (string, error) {
+	matches := findAcwScV2Reg.FindStringSubmatch(htmlContent)
+	if len(matches) != 2 {
+		return "", errors.New("无法匹配到 arg1 参数")
+	}
+	if len(matches[1]) != len(s.Box) || len(s.Mask) != len(s.Box) {
+		return "", fmt.Errorf("arg1 长度 %d 与 Box 长度 %d 不一致", len(matches[1]), len(s.Box))
+	}
+
+	result, err := hexXor(unbox(matches[1], s.Box), s.Mask)
+	if err != nil {
+		return "", fmt.Errorf("hexXor 操作失败: %w", err)
+	}
+	return result, nil
+}
+
+// 使用默认参数计算 acw_sc__v2
+func CalcAcwScV2(htmlContent string) (string, error) {
+	return DefaultAcwScV2Solver.Calc(htmlContent)
+}
+
+func unbox(hex string, box []int) string {
+	var newBox = make([]byte, len(hex))
+	for i, j := range box {
+		if len(newBox) > j && len(hex) > i {
+			newBox[j] = hex[i]
+		}
+	}
+	return string(newBox)
+}
+
+func hexXor(hex1, hex2 string) (string, error) {
+	bytes1, err := hex.DecodeString(hex1)
+	if err != nil {
+		return "", fmt.Errorf("解码 hex1 失败: %w", err)
+	}
+	bytes2, err := hex.DecodeString(hex2)
+	if err != nil {
+		return "", fmt.Errorf("解码 hex2 失败: %w", err)
+	}
+	if len(bytes1) != len(bytes2) {
+		return "", fmt.Errorf("长度不一致: %d != %d", len(bytes1), len(bytes2))
+	}
+	resultBytes := make([]byte, len(bytes1))
+	for i := range resultBytes {
+		resultBytes[i] = bytes1[i] ^ bytes2[i]
+	}
+	return hex.EncodeToString(resultBytes), nil
+}
@@ -0,0 +1,142 @@
+package lanzou
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"resty.dev/v3"
+)
+
+// 页面脚本由 node 执行得到的 cookie 值,见 testdata/challenge/acw_sc__v2.js
+const acwScV2Value = "7a4ff138b34490e394f2506c3828ce9c860d6fa6"
+
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// 替换注册的验证,测试结束后恢复
+func setChallengeSolvers(t *testing.T, solvers ...ChallengeSolver) {
+	challengeMu.Lock()
+	old := challengeSolvers
+	challengeSolvers = solvers
+	challengeMu.Unlock()
+	t.Cleanup(func() {
+		challengeMu.Lock()
+		challengeSolvers = old
+		challengeMu.Unlock()
+	})
+}
+
+func TestAcwScV2Detect(t *testing.T) {
+	page := readTestdata(t, "challenge/acw_sc__v2.html")
+	if !DefaultAcwScV2Solver.Detect(page) {
+		t.Fatal("acw_sc__v2 page not detected")
+	}
+	// 普通页面中出现 cookie 名称不是验证
+	if DefaultAcwScV2Solver.Detect(readTestdata(t, "challenge/cookie_script.html")) {
+		t.Fatal("page that only mentions acw_sc__v2 detected")
+	}
+	// arg1 长度与 Box 不一致的是其他版本
+	other := strings.Replace(page, "var arg1='", "var arg1='00", 1)
+	if DefaultAcwScV2Solver.Detect(other) {
+		t.Fatal("acw_sc__v2 page of another version detected")
+	}
+}
+
+func TestAcwScV2Calc(t *testing.T) {
+	value, err := CalcAcwScV2(readTestdata(t, "challenge/acw_sc__v2.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.TrimSpace(readTestdata(t, "challenge/acw_sc__v2.want"))
+	if value != want || want != acwScV2Value {
+		t.Fatalf("acw_sc__v2 = %s, want %s", value, want)
+	}
+	if _, err := hexXor("00ff", "00"); err == nil {
+		t.Fatal("hexXor with mismatched length succeeded")
+	}
+}
+
+// 返回验证页面,直到 cookie 正确
+type challengeServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests int
+}
+
+func newChallengeServer(t *testing.T, page string) *challengeServer {
+	s := &challengeServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests++
+		s.mu.Unlock()
+		if cookie, err := r.Cookie("acw_sc__v2"); err == nil && cookie.Value == acwScV2Value {
+			w.Write([]byte("ok"))
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func challengeClient() *resty.Client {
+	jar, _ := cookiejar.New(nil)
+	return WithChallenge(resty.New().SetCookieJar(jar), jar).
+		SetRetryCount(5).
+		SetRetryWaitTime(time.Millisecond).
+		SetRetryMaxWaitTime(time.Millisecond)
+}
+
+// 计算结果错误的验证
+type wrongSolver struct{ name string }
+
+func (s wrongSolver) Name() string            { return s.name }
+func (s wrongSolver) Detect(body string) bool { return strings.Contains(body, "acw_sc__v2") }
+func (s wrongSolver) Solve(string) (*ChallengeResult, error) {
+	return &ChallengeResult{Cookies: []*http.Cookie{{Name: "acw_sc__v2", Value: "0000"}}}, nil
+}
+
+func TestChallengeRetry(t *testing.T) {
+	page := readTestdata(t, "challenge/acw_sc__v2.html")
+
+	t.Run("solved", func(t *testing.T) {
+		setChallengeSolvers(t, DefaultAcwScV2Solver)
+		srv := newChallengeServer(t, page)
+		resp, err := challengeClient().R().Get(srv.URL)
+		if err != nil || resp.String() != "ok" || srv.requests != 2 {
+			t.Fatalf("body %q, requests %d, err %v", resp.String(), srv.requests, err)
+		}
+	})
+
+	// 第一个验证的结果被拒绝后换下一个
+	t.Run("next solver", func(t *testing.T) {
+		setChallengeSolvers(t, wrongSolver{"wrong"}, DefaultAcwScV2Solver)
+		srv := newChallengeServer(t, page)
+		resp, err := challengeClient().R().Get(srv.URL)
+		if err != nil || resp.String() != "ok" || srv.requests != 3 {
+			t.Fatalf("body %q, requests %d, err %v", resp.String(), srv.requests, err)
+		}
+	})
+
+	// 所有验证都被拒绝后不再重试
+	t.Run("all rejected", func(t *testing.T) {
+		setChallengeSolvers(t, wrongSolver{"wrong1"}, wrongSolver{"wrong2"})
+		srv := newChallengeServer(t, page)
+		resp, err := challengeClient().R().Get(srv.URL)
+		if err != nil || resp.String() == "ok" || srv.requests != 3 {
+			t.Fatalf("body %q, requests %d, err %v", resp.String(), srv.requests, err)
+		}
+	})
+}
@@ -0,0 +1,339 @@
+package lanzou
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"golang.org/x/sync/singleflight"
+	"resty.dev/v3"
+)
+
+/*
+蓝奏云接口
+只负责请求和解析,配置保存、缓存等由插件处理
+*/
+
+type Client struct {
+	BaseUrl  string
+	ShareUrl string
+
+	CookieJar         http.CookieJar
+	Client            *resty.Client
+	ClientNotRedirect *resty.Client
+	UploadClient      *resty.Client
+
+	// 加密子文件夹的提取码,以分享ID为键
+	FolderPasswords map[string]string
+
+	// cookie 过期时重新登录,为 nil 时直接返回错误
+	Relogin func() error
+	// uid/vei 更新后调用
+	OnParamsChanged func()
+
+	mu  sync.Mutex
+	uid string
+	vei string
+
+	loginGroup singleflight.Group
+}
+
+type ReqCallback func(client *resty.Request)
+
+func (c *Client) Doupload(callback ReqCallback, resp interface{}) ([]byte, error) {
+	doupload := func() ([]byte, error) {
+		return c.Post(MustUrlJoin(c.BaseUrl, "/doupload.php"), func(req *resty.Request) {
+			vei, uid := c.Params()
+			req.SetQueryParams(map[string]string{
+				"uid": uid,
+				"vei": vei,
+			})
+			if callback != nil {
+				callback(req)
+			}
+		}, resp)
+	}
+
+	data, err := doupload()
+	if err = douploadError(data, err); !errors.Is(err, ErrStaleParams) {
+		return data, err
+	}
+	// uid/vei 失效后重新获取再重试
+	if rerr := c.RefreshVeiAndUid(); rerr != nil {
+		return nil, errors.Join(err, rerr)
+	}
+	data, err = doupload()
+	return data, douploadError(data, err)
+}
+
+// 匹配 doupload.php 特有的错误
+func douploadError(data []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	zt, info := respInfo(data)
+	if ierr := infoToError(douploadInfoErrors, zt, info); ierr != nil {
+		return fmt.Errorf("%w: %s", ierr, info)
+	}
+	return err
+}
+
+func (c *Client) Post(url string, callback ReqCallback, resp interface{}) ([]byte, error) {
+	return c.Request(http.MethodPost, url, callback, resp, false)
+}
+
+func (c *Client) Get(url string, callback ReqCallback) ([]byte, error) {
+	return c.Request(http.MethodGet, url, callback, nil, false)
+}
+
+func (c *Client) Request(method string, url string, callback ReqCallback, resp interface{}, up bool) ([]byte, error) {
+	data, err := c.request(method, url, callback, resp, up)
+	// 只有在 cookie 过期时才需要特殊处理
+	if !errors.Is(err, ErrCookieExpiration) || c.Relogin == nil {
+		return data, err
+	}
+
+	// 使用 singleflight 来执行登录
+	// 所有遇到 cookie 过期的 goroutine 都会调用 Do,
+	// 但只有第一个会执行登录,其他的会等待结果。
+	_, err, _ = c.loginGroup.Do("login", func() (any, error) {
+		if loginErr := c.Relogin(); loginErr != nil {
+			return 0, errors.Join(err, loginErr)
+		}
+		// 新会话的 uid/vei 可能变化
+		if err := c.UpdateVeiAndUid(); err != nil {
+			return 0, err
+		}
+		return 0, nil
+	})
+	// 检查登录过程是否出错
+	if err != nil {
+		return nil, err // 返回合并后的错误
+	}
+
+	// 登录成功后，重试原始的 post 请求
+	return c.request(method, url, callback, resp, up)
+}
+
+func (c *Client) request(method string, url_ string, callback ReqCallback, resp any, up bool) ([]byte, error) {
+	var client *resty.Client
+	if up {
+		client = c.UploadClient
+	} else {
+		client = c.Client
+	}
+	req := client.R()
+
+	if callback != nil {
+		callback(req)
+	}
+
+	result, err := req.Execute(method, url_)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return checkError(result, resp)
+}
+
+// 检测可能的错误
+func checkError(result *resty.Response, resp any) ([]byte, error) {
+	data := result.Bytes()
+	// UserAgent 被屏蔽的话返回的数据是空的
+	if len(data) == 0 && result.StatusCode() == 200 {
+		return nil, ErrUserAgentBlocked
+	}
+
+	zt := gjson.GetBytes(data, "zt")
+	if zt.Raw == "" {
+		return data, nil
+	}
+	// 处理json错误
+	switch zt.Int() {
+	case 1, 2:
+		if resp != nil {
+			json.Unmarshal(data, resp)
+		}
+		return data, nil
+	case 4: // 操作繁忙,重试后仍然繁忙
+		return data, ErrRateLimited
+	case 9: // 登录过期
+		return data, ErrCookieExpiration
+	default:
+		_, info := respInfo(data)
+		if err := infoToError(infoErrors, zt.Int(), info); err != nil {
+			return data, fmt.Errorf("%w: %s", err, info)
+		}
+		return data, errors.New("error code: " + info)
+	}
+}
+
+// 返回 zt 和提示信息,没有提示时为整个响应
+func respInfo(data []byte) (int64, string) {
+	info := gjson.GetBytes(data, "inf").String()
+	if info == "" {
+		info = gjson.GetBytes(data, "info").String()
+	}
+	if info == "" {
+		info = string(data)
+	}
+	return gjson.GetBytes(data, "zt").Int(), info
+}
+
+// 登录风控返回的提示和字段,参考 testdata/login
+// 只匹配滑块验证,短信验证码等其他提示按登录失败处理
+var loginVerifyKeywords = []string{"滑动验证", "滑块验证", "人机验证", "captcha"}
+var loginVerifyFields = []string{"scene", "appkey"}
+
+// 判断登录响应是否要求验证
+func isLoginVerify(data []byte) bool {
+	info := gjson.GetBytes(data, "info").String() + gjson.GetBytes(data, "inf").String()
+	for _, k := range loginVerifyKeywords {
+		if strings.Contains(strings.ToLower(info), k) {
+			return true
+		}
+	}
+	for _, f := range loginVerifyFields {
+		if gjson.GetBytes(data, f).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+// 登录验证结果
+type LoginVerify struct {
+	SessionId string
+	Sig       string
+	Scene     string
+	Token     string
+	Formhash  string
+}
+
+// 使用账号密码登录,verify 为已完成的验证,可以为 nil
+func (c *Client) Login(account, password string, verify *LoginVerify) ([]*http.Cookie, error) {
+	form := map[string]string{
+		"task":         "3",
+		"uid":          account,
+		"pwd":          password,
+		"setSessionId": "",
+		"setSig":       "",
+		"setScene":     "",
+		"setTocen":     "",
+		"formhash":     "",
+	}
+	if verify != nil {
+		form["setSessionId"] = verify.SessionId
+		form["setSig"] = verify.Sig
+		form["setScene"] = verify.Scene
+		form["setTocen"] = verify.Token
+		form["formhash"] = verify.Formhash
+	}
+
+	resp, err := c.ClientNotRedirect.R().SetFormData(form).Post(MustUrlJoin(c.BaseUrl, "/mlogin.php"))
+	if err != nil {
+		return nil, errors.Join(err, ErrCookieExpiration)
+	}
+
+	data := resp.Bytes()
+	if gjson.GetBytes(data, "zt").Int() != 1 {
+		if isLoginVerify(data) {
+			verr := &LoginVerifyError{Info: gjson.GetBytes(data, "info").String()}
+			if verr.Info == "" {
+				verr.Info = gjson.GetBytes(data, "inf").String()
+			}
+			json.Unmarshal(data, &verr.Data)
+			log.Warnf("lanzou: login requires verification, data => %s\n", data)
+			return nil, verr
+		}
+		return nil, fmt.Errorf("%w: login err: %s", ErrCookieExpiration, data)
+	}
+
+	//  302 说明Cookie没有过期
+	if resp.StatusCode() == 302 {
+		return c.CookieJar.Cookies(resp.RawResponse.Request.URL), nil
+	}
+
+	return resp.Cookies(), nil
+}
+
+var findUidReg = regexp.MustCompile(`uid=([^'"&;]+)`)
+
+// 未登录时 mydisk.php 返回登录页面
+var isLoginPageReg = regexp.MustCompile(`(?i)mlogin\.php|action=login|用户登录`)
+
+// 获取 uid 和 vei
+// 不经过 Request 的重新登录流程,可以在登录的 singleflight 中调用
+func (c *Client) GetVeiAndUid() (vei string, uid string, err error) {
+	var resp []byte
+	resp, err = c.request(http.MethodGet, MustUrlJoin(c.BaseUrl, "/mydisk.php"), func(client *resty.Request) {
+		client.SetQueryParams(map[string]string{
+			"item":   "files",
+			"action": "index",
+		})
+	}, nil, false)
+	if err != nil {
+		return
+	}
+
+	page := RemoveNotes(string(resp))
+	uids := findUidReg.FindStringSubmatch(page)
+	if len(uids) < 2 {
+		if isLoginPageReg.MatchString(page) {
+			err = fmt.Errorf("%w: mydisk.php returned the login page", ErrCookieExpiration)
+		} else {
+			err = fmt.Errorf("%w: uid not found in mydisk.php (%d bytes)", ErrTemplateChanged, len(page))
+		}
+		return
+	}
+	uid = uids[1]
+
+	data, err := htmlJsonToMap(page)
+	if err != nil {
+		err = fmt.Errorf("vei: %w", err)
+		return
+	}
+	vei = data["vei"]
+	if vei == "" {
+		err = fmt.Errorf("%w: vei not found in mydisk.php data object", ErrTemplateChanged)
+	}
+	return
+}
+
+// 重新获取 uid 和 vei,与登录共用 singleflight,登录进行中时等待登录结果
+func (c *Client) RefreshVeiAndUid() error {
+	_, err, _ := c.loginGroup.Do("login", func() (any, error) {
+		return 0, c.UpdateVeiAndUid()
+	})
+	return err
+}
+
+func (c *Client) UpdateVeiAndUid() error {
+	vei, uid, err := c.GetVeiAndUid()
+	if err != nil {
+		return err
+	}
+	c.SetParams(vei, uid)
+	if c.OnParamsChanged != nil {
+		c.OnParamsChanged()
+	}
+	return nil
+}
+
+func (c *Client) Params() (vei, uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.vei, c.uid
+}
+
+func (c *Client) SetParams(vei, uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vei, c.uid = vei, uid
+}
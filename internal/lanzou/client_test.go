@@ -0,0 +1,223 @@
+package lanzou
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"resty.dev/v3"
+)
+
+func TestLogin(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.client()
+
+	if _, err := c.Login(fakeAccount, "wrong", nil); !errors.Is(err, ErrCookieExpiration) {
+		t.Fatalf("wrong password: got %v, want ErrCookieExpiration", err)
+	}
+
+	cookies, err := c.Login(fakeAccount, fakePassword, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasCookie(cookies, "phpdisk_info") {
+		t.Fatalf("login cookies %v missing phpdisk_info", cookies)
+	}
+
+	if err := c.UpdateVeiAndUid(); err != nil {
+		t.Fatal(err)
+	}
+	if vei, uid := c.Params(); vei != "VEI0" || uid != fakeUid {
+		t.Fatalf("params = %q %q", vei, uid)
+	}
+}
+
+func TestIsLoginVerify(t *testing.T) {
+	for name, want := range map[string]bool{
+		"ok.json":              false,
+		"wrong_password.json":  false,
+		"no_account.json":      false,
+		"sms_code.json":        false,
+		"verify_required.json": true,
+		"verify_failed.json":   true,
+	} {
+		if got := isLoginVerify([]byte(readTestdata(t, "login/"+name))); got != want {
+			t.Errorf("%s: isLoginVerify = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestLoginVerify(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.client()
+	f.loginVerify = true
+
+	var verr *LoginVerifyError
+	_, err := c.Login(fakeAccount, fakePassword, nil)
+	if !errors.As(err, &verr) || !errors.Is(err, ErrVerificationRequired) {
+		t.Fatalf("no verify: got %v, want LoginVerifyError", err)
+	}
+	if verr.Data["scene"] != "nc_login" || verr.Info == "" {
+		t.Fatalf("verify error = %+v", verr)
+	}
+
+	// 验证结果被拒绝时仍要求验证
+	if _, err := c.Login(fakeAccount, fakePassword, &LoginVerify{Sig: "expired"}); !errors.Is(err, ErrVerificationRequired) {
+		t.Fatalf("rejected verify: got %v, want ErrVerificationRequired", err)
+	}
+
+	if _, err := c.Login(fakeAccount, fakePassword, &LoginVerify{SessionId: "s", Sig: fakeVerifySig, Scene: "nc_login"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Login(fakeAccount, fakePassword, nil); err != nil {
+		t.Fatalf("login after verify: %v", err)
+	}
+}
+
+// 重新登录要求验证时返回验证错误
+func TestReloginVerify(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	c.Relogin = func() error {
+		_, err := c.Login(fakeAccount, fakePassword, nil)
+		return err
+	}
+	f.expireSession()
+	f.loginVerify = true
+	if _, err := c.GetFolders("-1"); !errors.Is(err, ErrVerificationRequired) {
+		t.Fatalf("got %v, want ErrVerificationRequired", err)
+	}
+}
+
+func TestGetVeiAndUidLoginPage(t *testing.T) {
+	f := newFakeLanZou(t)
+	if _, _, err := f.client().GetVeiAndUid(); !errors.Is(err, ErrCookieExpiration) {
+		t.Fatalf("got %v, want ErrCookieExpiration", err)
+	}
+}
+
+func TestReloginOnCookieExpiration(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	relogins := 0
+	c.Relogin = func() error {
+		relogins++
+		_, err := c.Login(fakeAccount, fakePassword, nil)
+		return err
+	}
+	f.addFolder("-1", "docs", "")
+
+	f.expireSession()
+	folders, err := c.GetFolders("-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if relogins != 1 || len(folders) != 1 {
+		t.Fatalf("relogins = %d, folders = %v", relogins, folders)
+	}
+}
+
+func TestCookieExpirationWithoutRelogin(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	f.expireSession()
+	if _, err := c.GetFolders("-1"); !errors.Is(err, ErrCookieExpiration) {
+		t.Fatalf("got %v, want ErrCookieExpiration", err)
+	}
+}
+
+func TestStaleParamsRefresh(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	changed := 0
+	c.OnParamsChanged = func() { changed++ }
+
+	f.rotateVei()
+	if _, err := c.GetFolders("-1"); err != nil {
+		t.Fatal(err)
+	}
+	if changed != 1 || f.calledTimes("doupload:47") != 2 {
+		t.Fatalf("params changed %d times, task 47 called %d times", changed, f.calledTimes("doupload:47"))
+	}
+}
+
+func TestBusy(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	f.addFolder("-1", "docs", "")
+	f.busy = true
+	// 繁忙时不能当作空列表
+	if folders, err := c.GetFolders("-1"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("got %v, %v, want ErrRateLimited", folders, err)
+	}
+}
+
+func TestCheckError(t *testing.T) {
+	// 没有收录的提示按普通错误返回
+	// 非法请求只在 doupload.php 中表示 uid/vei 失效,分享页面接口中是页面参数错误
+	wants := map[string]error{
+		"stale_params.json":          nil,
+		"busy.json":                  ErrRateLimited,
+		"folder_wrong_password.json": ErrWrongPassword,
+		"file_wrong_password.json":   ErrWrongPassword,
+		"file_not_exist.json":        ErrFileNotExist,
+		"folder_not_exist.json":      ErrFileNotExist,
+		"parent_not_exist.json":      ErrFileNotExist,
+		"file_type_rejected.json":    ErrFileTypeRejected,
+		"session_expired.json":       ErrCookieExpiration,
+		"param_error.json":           nil,
+		"folder_not_empty.json":      nil,
+		"folder_name_format.json":    nil,
+		"desc_too_long.json":         nil,
+		"upload_failed.json":         nil,
+		"sign_error.json":            nil,
+	}
+	entries, err := os.ReadDir("testdata/info")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(wants) {
+		t.Fatalf("%d fixtures in testdata/info, %d expected", len(entries), len(wants))
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(readTestdata(t, "info/"+strings.TrimPrefix(r.URL.Path, "/"))))
+	}))
+	defer srv.Close()
+	client := resty.New()
+	defer client.Close()
+
+	kinds := []error{ErrStaleParams, ErrRateLimited, ErrWrongPassword, ErrFileNotExist, ErrFileTypeRejected, ErrCookieExpiration}
+	for name, want := range wants {
+		result, err := client.R().Get(srv.URL + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := checkError(result, nil)
+		if err == nil {
+			t.Errorf("%s: no error", name)
+			continue
+		}
+		if err := douploadError(data, err); errors.Is(err, ErrStaleParams) != (name == "stale_params.json") {
+			t.Errorf("%s: doupload got %v", name, err)
+		}
+		for _, kind := range kinds {
+			if errors.Is(err, kind) != (kind == want) {
+				t.Errorf("%s: got %v, want %v", name, err, want)
+				break
+			}
+		}
+	}
+}
+
+func hasCookie(cookies []*http.Cookie, name string) bool {
+	for _, cookie := range cookies {
+		if cookie.Name == name && cookie.Value != "" {
+			return true
+		}
+	}
+	return false
+}
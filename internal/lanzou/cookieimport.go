@@ -0,0 +1,304 @@
+package lanzou
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"resty.dev/v3/cookiejar"
+)
+
+/*
+Cookie 导入
+支持三种格式: 请求头格式(a=b; c=d)、Netscape cookies.txt 和浏览器扩展导出的 JSON
+后两种格式保留每个 cookie 的域名、路径和过期时间
+*/
+
+type cookieFormat int
+
+const (
+	cookieFormatHeader cookieFormat = iota
+	cookieFormatNetscape
+	cookieFormatJSON
+)
+
+const netscapeHttpOnlyPrefix = "#HttpOnly_"
+
+func detectCookieFormat(raw string) cookieFormat {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case strings.HasPrefix(raw, "[") || strings.HasPrefix(raw, "{"):
+		return cookieFormatJSON
+	case strings.HasPrefix(raw, "# Netscape") || strings.HasPrefix(raw, "# HTTP Cookie File") || strings.Contains(raw, "\t"):
+		return cookieFormatNetscape
+	}
+	return cookieFormatHeader
+}
+
+// 解析 Cookie 配置
+func ParseCookieConfig(raw string) ([]*http.Cookie, error) {
+	switch detectCookieFormat(raw) {
+	case cookieFormatJSON:
+		return parseJSONCookies(raw)
+	case cookieFormatNetscape:
+		return parseNetscapeCookies(raw)
+	}
+	return cookiejar.ParseCookie(strings.TrimSpace(raw))
+}
+
+// Netscape cookies.txt,每行: domain includeSubdomains path secure expiry name value
+func parseNetscapeCookies(raw string) ([]*http.Cookie, error) {
+	var cookies []*http.Cookie
+	for i, line := range strings.Split(raw, "\n") {
+		line = strings.TrimRight(line, "\r")
+		httpOnly := strings.HasPrefix(line, netscapeHttpOnlyPrefix)
+		line = strings.TrimPrefix(line, netscapeHttpOnlyPrefix)
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("cookies.txt line %d: expect 7 fields, got %d", i+1, len(fields))
+		}
+		cookie := &http.Cookie{
+			Name:     fields[5],
+			Value:    strings.Join(fields[6:], "\t"),
+			Domain:   netscapeDomain(fields[0], strings.EqualFold(fields[1], "TRUE")),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expiry, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expiry > 0 {
+			cookie.Expires = time.Unix(expiry, 0)
+		}
+		cookies = append(cookies, cookie)
+	}
+	if len(cookies) == 0 {
+		return nil, errors.New("cookies.txt: no cookie found")
+	}
+	return cookies, nil
+}
+
+// 不包含子域名的 cookie 只发送给该域名
+func netscapeDomain(domain string, subdomains bool) string {
+	if subdomains {
+		return "." + strings.TrimPrefix(domain, ".")
+	}
+	return strings.TrimPrefix(domain, ".")
+}
+
+// 浏览器扩展导出的 JSON cookie (EditThisCookie, Cookie-Editor 等)
+type jsonCookie struct {
+	Name           string   `json:"name"`
+	Value          string   `json:"value"`
+	Domain         string   `json:"domain"`
+	Path           string   `json:"path"`
+	Secure         bool     `json:"secure"`
+	HttpOnly       bool     `json:"httpOnly"`
+	HostOnly       bool     `json:"hostOnly"`
+	Session        bool     `json:"session"`
+	ExpirationDate *float64 `json:"expirationDate"`
+	Expires        any      `json:"expires"` // 部分扩展使用时间戳或时间字符串
+}
+
+func parseJSONCookies(raw string) ([]*http.Cookie, error) {
+	var list []jsonCookie
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "{") {
+		// 兼容 {"cookies": [...]} 格式
+		var wrapped struct {
+			Cookies []jsonCookie `json:"cookies"`
+		}
+		if err := json.Unmarshal([]byte(raw), &wrapped); err != nil {
+			return nil, fmt.Errorf("cookie json: %w", err)
+		}
+		list = wrapped.Cookies
+	} else if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, fmt.Errorf("cookie json: %w", err)
+	}
+
+	cookies := make([]*http.Cookie, 0, len(list))
+	for _, c := range list {
+		if c.Name == "" {
+			continue
+		}
+		cookie := &http.Cookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		if c.HostOnly {
+			cookie.Domain = strings.TrimPrefix(c.Domain, ".")
+		}
+		if !c.Session {
+			cookie.Expires = jsonCookieExpiry(c)
+		}
+		cookies = append(cookies, cookie)
+	}
+	if len(cookies) == 0 {
+		return nil, errors.New("cookie json: no cookie found")
+	}
+	return cookies, nil
+}
+
+func jsonCookieExpiry(c jsonCookie) time.Time {
+	if c.ExpirationDate != nil {
+		sec, frac := math.Modf(*c.ExpirationDate)
+		return time.Unix(int64(sec), int64(frac*1e9))
+	}
+	switch v := c.Expires.(type) {
+	case float64:
+		// 毫秒时间戳
+		if v > 1e11 {
+			return time.UnixMilli(int64(v))
+		}
+		return time.Unix(int64(v), 0)
+	case string:
+		for _, layout := range []string{time.RFC3339, time.RFC1123, http.TimeFormat} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// 更新 Cookie 配置中的值,保持原有格式
+func UpdateCookieConfig(raw string, cookie *http.Cookie) string {
+	switch detectCookieFormat(raw) {
+	case cookieFormatJSON:
+		return updateJSONCookie(raw, cookie)
+	case cookieFormatNetscape:
+		return updateNetscapeCookie(raw, cookie)
+	}
+	return setCookieValue(raw, cookie.Name, cookie.Value)
+}
+
+func updateNetscapeCookie(raw string, cookie *http.Cookie) string {
+	lines := strings.Split(raw, "\n")
+	changed := false
+	for i, line := range lines {
+		prefix := ""
+		if strings.HasPrefix(line, netscapeHttpOnlyPrefix) {
+			prefix = netscapeHttpOnlyPrefix
+		} else if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(strings.TrimPrefix(strings.TrimRight(line, "\r"), prefix), "\t")
+		if len(fields) < 7 || fields[5] != cookie.Name {
+			continue
+		}
+		fields = append(fields[:6], cookie.Value)
+		if expires := CookieExpiry(cookie); !expires.IsZero() {
+			if old, _ := strconv.ParseInt(fields[4], 10, 64); ExpiryChanged(old, expires) {
+				fields[4] = strconv.FormatInt(expires.Unix(), 10)
+			}
+		}
+		if updated := prefix + strings.Join(fields, "\t"); updated != strings.TrimRight(line, "\r") {
+			lines[i] = updated
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON 格式使用通用结构修改,保留扩展导出的其他字段
+func updateJSONCookie(raw string, cookie *http.Cookie) string {
+	var wrapped map[string]any
+	var list []any
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "{") {
+		if json.Unmarshal([]byte(trimmed), &wrapped) != nil {
+			return raw
+		}
+		list, _ = wrapped["cookies"].([]any)
+	} else if json.Unmarshal([]byte(trimmed), &list) != nil {
+		return raw
+	}
+
+	changed := false
+	expires := CookieExpiry(cookie)
+	for _, item := range list {
+		c, ok := item.(map[string]any)
+		if !ok || c["name"] != cookie.Name {
+			continue
+		}
+		if c["value"] != cookie.Value {
+			c["value"] = cookie.Value
+			changed = true
+		}
+		if old, _ := c["expirationDate"].(float64); !expires.IsZero() && ExpiryChanged(int64(old), expires) {
+			c["expirationDate"] = float64(expires.Unix())
+			c["session"] = false
+			changed = true
+		}
+	}
+	if !changed {
+		return raw
+	}
+
+	var data []byte
+	var err error
+	if wrapped != nil {
+		wrapped["cookies"] = list
+		data, err = json.Marshal(wrapped)
+	} else {
+		data, err = json.Marshal(list)
+	}
+	if err != nil {
+		return raw
+	}
+	return string(data)
+}
+
+// cookie 的过期时间,会话 cookie 返回零值
+func CookieExpiry(cookie *http.Cookie) time.Time {
+	if cookie.MaxAge > 0 {
+		return time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+	}
+	if !cookie.Expires.IsZero() {
+		return cookie.Expires
+	}
+	return time.Time{}
+}
+
+// 替换 cookie 字符串中的值,不存在时追加
+func setCookieValue(cookie, name, value string) string {
+	parts := strings.Split(cookie, ";")
+	found := false
+	for i, part := range parts {
+		k, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if k == name {
+			parts[i] = name + "=" + value
+			found = true
+		}
+	}
+	if !found {
+		if strings.TrimSpace(cookie) == "" {
+			return name + "=" + value
+		}
+		parts = append(parts, name+"="+value)
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Max-Age 每次计算结果略有差异,变化超过一分钟才视为刷新
+func ExpiryChanged(old int64, expires time.Time) bool {
+	diff := expires.Unix() - old
+	return diff > 60 || diff < -60
+}
@@ -0,0 +1,129 @@
+package lanzou
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testNetscapeCookies = "# Netscape HTTP Cookie File\n" +
+		".woozooo.com\tTRUE\t/\tFALSE\t1893456000\tylogin\t1234567\n" +
+		"#HttpOnly_pc.woozooo.com\tFALSE\t/\tTRUE\t1893456000\tphpdisk_info\tVmNSYl0%2BAT\r\n" +
+		"\n"
+	testJSONCookies = `[
+{"name":"ylogin","value":"1234567","domain":".woozooo.com","path":"/","hostOnly":false,"session":false,"expirationDate":1893456000.5},
+{"name":"phpdisk_info","value":"VmNSYl0%2BAT","domain":".pc.woozooo.com","path":"/","httpOnly":true,"secure":true,"hostOnly":true,"session":true,"storeId":"0"}
+]`
+	testWrappedJSONCookies = `{"url":"https://pc.woozooo.com","cookies":[{"name":"phpdisk_info","value":"VmNSYl0%2BAT","expires":1893456000000},{"name":"ylogin","value":"1234567","expires":"2030-01-01T00:00:00Z"}]}`
+)
+
+func TestParseCookieConfig(t *testing.T) {
+	expiry := time.Unix(1893456000, 0)
+	tests := []struct {
+		name string
+		raw  string
+		want []http.Cookie
+	}{
+		{"header", " ylogin=1234567; phpdisk_info=VmNSYl0%2BAT ", []http.Cookie{
+			{Name: "ylogin", Value: "1234567"},
+			{Name: "phpdisk_info", Value: "VmNSYl0%2BAT"},
+		}},
+		{"netscape", testNetscapeCookies, []http.Cookie{
+			{Name: "ylogin", Value: "1234567", Domain: ".woozooo.com", Path: "/", Expires: expiry},
+			{Name: "phpdisk_info", Value: "VmNSYl0%2BAT", Domain: "pc.woozooo.com", Path: "/", Secure: true, HttpOnly: true, Expires: expiry},
+		}},
+		{"json", testJSONCookies, []http.Cookie{
+			{Name: "ylogin", Value: "1234567", Domain: ".woozooo.com", Path: "/", Expires: expiry.Add(500 * time.Millisecond)},
+			{Name: "phpdisk_info", Value: "VmNSYl0%2BAT", Domain: "pc.woozooo.com", Path: "/", Secure: true, HttpOnly: true},
+		}},
+		{"wrapped json", testWrappedJSONCookies, []http.Cookie{
+			{Name: "phpdisk_info", Value: "VmNSYl0%2BAT", Expires: expiry},
+			{Name: "ylogin", Value: "1234567", Expires: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cookies, err := ParseCookieConfig(tt.raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(cookies) != len(tt.want) {
+				t.Fatalf("got %d cookies, want %d", len(cookies), len(tt.want))
+			}
+			for i, c := range cookies {
+				w := tt.want[i]
+				if c.Name != w.Name || c.Value != w.Value || c.Domain != w.Domain || c.Path != w.Path ||
+					c.Secure != w.Secure || c.HttpOnly != w.HttpOnly || !c.Expires.Equal(w.Expires) {
+					t.Errorf("cookie %d = %+v, want %+v", i, *c, w)
+				}
+			}
+		})
+	}
+}
+
+func TestParseCookieConfigMalformed(t *testing.T) {
+	for name, raw := range map[string]string{
+		"short netscape line": "# Netscape HTTP Cookie File\n.woozooo.com\tTRUE\t/\tFALSE\tylogin\n",
+		"netscape comments":   "# Netscape HTTP Cookie File\n# nothing here\n",
+		"broken json":         `[{"name":"ylogin","value":`,
+		"json without names":  `[{"value":"1234567"}]`,
+		"empty wrapped json":  `{"cookies":[]}`,
+		"wrong json type":     `{"cookies":"ylogin=1"}`,
+	} {
+		if cookies, err := ParseCookieConfig(raw); err == nil {
+			t.Errorf("%s: got %v, want error", name, cookies)
+		}
+	}
+}
+
+func TestUpdateCookieConfig(t *testing.T) {
+	expires := time.Unix(1900000000, 0)
+	refreshed := &http.Cookie{Name: "phpdisk_info", Value: "NEW", Expires: expires}
+	for name, raw := range map[string]string{
+		"header":       "ylogin=1234567; phpdisk_info=VmNSYl0%2BAT",
+		"netscape":     testNetscapeCookies,
+		"json":         testJSONCookies,
+		"wrapped json": testWrappedJSONCookies,
+	} {
+		t.Run(name, func(t *testing.T) {
+			updated := UpdateCookieConfig(raw, refreshed)
+			if detectCookieFormat(updated) != detectCookieFormat(raw) {
+				t.Fatalf("format changed: %q", updated)
+			}
+			cookies, err := ParseCookieConfig(updated)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]*http.Cookie{}
+			for _, c := range cookies {
+				got[c.Name] = c
+			}
+			if len(got) != 2 || got["ylogin"].Value != "1234567" || got["phpdisk_info"].Value != "NEW" {
+				t.Fatalf("cookies after update: %q", updated)
+			}
+			// 请求头格式没有过期时间
+			if name != "header" && !got["phpdisk_info"].Expires.Equal(expires) {
+				t.Fatalf("expiry after update = %v, want %v", got["phpdisk_info"].Expires, expires)
+			}
+			// 再次写入相同的值不修改配置
+			if again := UpdateCookieConfig(updated, refreshed); again != updated {
+				t.Fatalf("second update changed config:\n%s\n%s", updated, again)
+			}
+		})
+	}
+
+	// 请求头格式中不存在的 cookie 追加到末尾
+	if got := UpdateCookieConfig("ylogin=1", &http.Cookie{Name: "phpdisk_info", Value: "x"}); got != "ylogin=1; phpdisk_info=x" {
+		t.Fatalf("append = %q", got)
+	}
+	// 无法解析的 JSON 保持原样
+	if got := UpdateCookieConfig(`[{"name":`, refreshed); got != `[{"name":` {
+		t.Fatalf("broken json updated to %q", got)
+	}
+	// 其他字段保留
+	if updated := UpdateCookieConfig(testJSONCookies, refreshed); !strings.Contains(updated, `"storeId":"0"`) {
+		t.Fatalf("extension fields dropped: %s", updated)
+	}
+}
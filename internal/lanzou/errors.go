@@ -0,0 +1,118 @@
+package lanzou
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+/*
+错误分类
+每种错误属于一个分类,分类通过 AliasError 对应到 adapter 错误,OpenList 据此显示状态;没有分类的作为普通错误返回
+*/
+
+// 错误分类,本包不依赖 SDK
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotSupported = errors.New("not supported")
+)
+
+var (
+	errorAliasMu sync.RWMutex
+	errorAliases = make(map[error][]error)
+)
+
+// 将分类对应到外部错误,使 errors.Is(err, target) 对该分类的错误成立
+func AliasError(kind, target error) {
+	errorAliasMu.Lock()
+	defer errorAliasMu.Unlock()
+	errorAliases[kind] = append(errorAliases[kind], target)
+}
+
+type LanZouError struct {
+	msg  string
+	kind error // 错误分类或上级错误
+}
+
+func (e *LanZouError) Error() string { return e.msg }
+func (e *LanZouError) Unwrap() error { return e.kind }
+
+func (e *LanZouError) Is(target error) bool {
+	errorAliasMu.RLock()
+	defer errorAliasMu.RUnlock()
+	for _, alias := range errorAliases[e.kind] {
+		if alias == target {
+			return true
+		}
+	}
+	return false
+}
+
+func newLanZouError(msg string, kind error) *LanZouError {
+	return &LanZouError{msg: msg, kind: kind}
+}
+
+var (
+	ErrWrongPassword         = newLanZouError("wrong share password", ErrUnauthorized)
+	ErrSharePasswordRequired = newLanZouError("share password required", ErrUnauthorized)
+	ErrFileShareCancel       = newLanZouError("file sharing cancellation", ErrNotFound)
+	ErrFileNotExist          = newLanZouError("file does not exist", ErrNotFound)
+	ErrCookieExpiration      = newLanZouError("cookie expiration", ErrUnauthorized)
+	ErrRateLimited           = newLanZouError("operation too frequent, please try again later", nil)
+	ErrUserAgentBlocked      = newLanZouError("page cannot be retrieved, please try using a new UserAgent", nil)
+	ErrFileTypeRejected      = newLanZouError("file type is not allowed", nil)
+	ErrTemplateChanged       = newLanZouError("page template changed", nil)
+	ErrVerificationRequired  = newLanZouError("login verification required", ErrUnauthorized)
+	ErrStaleParams           = newLanZouError("uid/vei parameters are stale", nil)
+	ErrMoveIntoSubfolder     = newLanZouError("cannot move or copy a folder into itself or its subfolder", ErrNotSupported)
+)
+
+// 会话过期的别名
+var ErrSessionExpired = ErrCookieExpiration
+
+// 接口返回的错误,zt 和提示信息都一致时才匹配,参考 testdata/info
+// 没有收录的提示按普通错误返回,避免误判后重新获取参数或重新登录
+type infoError struct {
+	zt   int64
+	info string
+	err  error
+}
+
+var infoErrors = []infoError{
+	{3, "密码不正确", ErrWrongPassword}, // filemoreajax
+	{0, "密码不正确", ErrWrongPassword}, // ajaxm
+	{0, "文件不存在", ErrFileNotExist},
+	{0, "文件夹不存在", ErrFileNotExist},
+	{0, "上级文件夹不存在", ErrFileNotExist},
+	{0, "不允许上传的文件格式", ErrFileTypeRejected},
+}
+
+// 只在 doupload.php 中成立的错误,分享页面接口返回同样的提示时是页面参数错误
+var douploadInfoErrors = []infoError{
+	{0, "非法请求", ErrStaleParams},
+}
+
+// 根据 zt 和提示信息匹配错误,没有匹配时返回 nil
+func infoToError(table []infoError, zt int64, info string) error {
+	info = strings.TrimSpace(info)
+	for _, ie := range table {
+		if ie.zt == zt && ie.info == info {
+			return ie.err
+		}
+	}
+	return nil
+}
+
+// 登录需要滑块或验证码验证,Data 为接口返回的验证数据
+type LoginVerifyError struct {
+	Info string
+	Data map[string]any
+}
+
+func (e *LoginVerifyError) Error() string {
+	return fmt.Sprintf("%s: %s, complete it and fill login_verify", ErrVerificationRequired, e.Info)
+}
+
+func (e *LoginVerifyError) Unwrap() error { return ErrVerificationRequired }
@@ -0,0 +1,623 @@
+package lanzou
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"resty.dev/v3"
+)
+
+/*
+本地模拟的蓝奏云
+只实现插件使用到的接口,返回内容参照真实页面的结构
+BaseUrl 和 ShareUrl 使用同一个服务
+*/
+
+const (
+	fakeAccount   = "13800000000"
+	fakePassword  = "secret"
+	fakeUid       = "1024"
+	fakePageSize  = 2 // filemoreajax 和 task 5 的分页大小,便于覆盖翻页
+	fakeVerifySig = "SIG-OK"
+)
+
+type fakeFolder struct {
+	ID, Parent, Name, Desc, Pwd string
+}
+
+type fakeFile struct {
+	ID, Folder, Name, Pwd string
+	Data                  []byte
+}
+
+type fakeLanZou struct {
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	nextID  int
+	session string // 有效的 phpdisk_info
+	vei     string
+	folders map[string]*fakeFolder
+	files   map[string]*fakeFile
+	calls   map[string]int // 接口调用次数,doupload 按 task 记录
+
+	// 下一次登录要求滑块验证
+	loginVerify bool
+	// doupload 返回操作繁忙
+	busy bool
+}
+
+func newFakeLanZou(t *testing.T) *fakeLanZou {
+	f := &fakeLanZou{
+		nextID:  100,
+		vei:     "VEI0",
+		folders: map[string]*fakeFolder{"-1": {ID: "-1", Name: "root"}},
+		files:   make(map[string]*fakeFile),
+		calls:   make(map[string]int),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/mlogin.php", f.handleLogin)
+	mux.HandleFunc("/mydisk.php", f.handleMydisk)
+	mux.HandleFunc("/doupload.php", f.handleDoupload)
+	mux.HandleFunc("/html5up.php", f.handleUpload)
+	mux.HandleFunc("/filemoreajax.php", f.handleFileMore)
+	mux.HandleFunc("/ajaxm.php", f.handleAjaxm)
+	mux.HandleFunc("/fn", f.handleFn)
+	mux.HandleFunc("/file/", f.handleRedirect)
+	mux.HandleFunc("/down/", f.handleDownload)
+	mux.HandleFunc("/", f.handleSharePage)
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// 与插件相同方式创建的客户端
+func (f *fakeLanZou) client() *Client {
+	jar, _ := cookiejar.New(nil)
+	newClient := func() *resty.Client {
+		return WithChallenge(resty.New().SetCookieJar(jar), jar).
+			SetRetryCount(3).
+			SetRetryWaitTime(time.Millisecond).
+			SetRetryMaxWaitTime(time.Millisecond)
+	}
+	return &Client{
+		BaseUrl:           f.srv.URL,
+		ShareUrl:          f.srv.URL,
+		CookieJar:         jar,
+		Client:            newClient(),
+		ClientNotRedirect: newClient().SetRedirectPolicy(resty.NoRedirectPolicy()),
+		UploadClient:      resty.New().SetCookieJar(jar),
+		FolderPasswords:   map[string]string{},
+	}
+}
+
+// 登录后的客户端
+func (f *fakeLanZou) login(t *testing.T) *Client {
+	c := f.client()
+	if _, err := c.Login(fakeAccount, fakePassword, nil); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if err := c.UpdateVeiAndUid(); err != nil {
+		t.Fatalf("update vei: %v", err)
+	}
+	return c
+}
+
+func (f *fakeLanZou) newID() string {
+	f.nextID++
+	return strconv.Itoa(f.nextID)
+}
+
+func (f *fakeLanZou) addFolder(parent, name, pwd string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := f.newID()
+	f.folders[id] = &fakeFolder{ID: id, Parent: parent, Name: name, Pwd: pwd}
+	return id
+}
+
+func (f *fakeLanZou) addFile(folder, name, pwd, data string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := f.newID()
+	f.files[id] = &fakeFile{ID: id, Folder: folder, Name: name, Pwd: pwd, Data: []byte(data)}
+	return id
+}
+
+func (f *fakeLanZou) calledTimes(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[name]
+}
+
+// 使当前会话失效,模拟 cookie 过期
+func (f *fakeLanZou) expireSession() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.session = ""
+}
+
+// 更换 vei,模拟页面参数轮换
+func (f *fakeLanZou) rotateVei() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.vei = "VEI" + f.newID()
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeLanZou) loggedIn(r *http.Request) bool {
+	c, err := r.Cookie("phpdisk_info")
+	return err == nil && f.session != "" && c.Value == f.session
+}
+
+func (f *fakeLanZou) handleLogin(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["mlogin"]++
+
+	if r.PostForm.Get("task") != "3" {
+		writeJSON(w, map[string]any{"zt": 0, "info": "非法请求"})
+		return
+	}
+	// 要求滑块验证时只接受 fakeVerifySig
+	if f.loginVerify {
+		switch r.PostForm.Get("setSig") {
+		case "":
+			f.writeTestdata(w, "login/verify_required.json")
+			return
+		case fakeVerifySig:
+			f.loginVerify = false
+		default:
+			f.writeTestdata(w, "login/verify_failed.json")
+			return
+		}
+	}
+	if r.PostForm.Get("uid") != fakeAccount {
+		f.writeTestdata(w, "login/no_account.json")
+		return
+	}
+	if r.PostForm.Get("pwd") != fakePassword {
+		f.writeTestdata(w, "login/wrong_password.json")
+		return
+	}
+	f.session = "SESSION" + f.newID()
+	http.SetCookie(w, &http.Cookie{Name: "phpdisk_info", Value: f.session, Path: "/", Expires: time.Now().Add(15 * 24 * time.Hour)})
+	http.SetCookie(w, &http.Cookie{Name: "ylogin", Value: fakeUid, Path: "/"})
+	f.writeTestdata(w, "login/ok.json")
+}
+
+// 返回 testdata 中记录的响应
+func (f *fakeLanZou) writeTestdata(w http.ResponseWriter, name string) {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/json;charset=UTF-8")
+	w.Write(data)
+}
+
+func (f *fakeLanZou) handleMydisk(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["mydisk"]++
+
+	if !f.loggedIn(r) {
+		fmt.Fprint(w, `<html><head><title>用户登录 - 蓝奏云</title></head><body><form action="mlogin.php" method="post"></form></body></html>`)
+		return
+	}
+	fmt.Fprintf(w, `<html><head><title>我的文件 - 蓝奏云</title></head><body>
+<iframe src="/mydisk.php?item=files&action=index&u=%[1]s"></iframe>
+<script type="text/javascript">
+<!-- var vei = 'OLD'; -->
+var wplo = '%[2]s';
+function folderlist(){
+	$.ajax({
+		type : 'post',
+		url : '/doupload.php?uid=%[1]s',
+		data : { 'task':47,'folder_id':-1,'vei':wplo },
+		dataType : 'json'
+	});
+}
+</script></body></html>`, fakeUid, f.vei)
+}
+
+func (f *fakeLanZou) handleDoupload(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	task := r.PostForm.Get("task")
+	f.calls["doupload:"+task]++
+
+	if !f.loggedIn(r) {
+		writeJSON(w, map[string]any{"zt": 9, "info": "登录信息已失效"})
+		return
+	}
+	if r.URL.Query().Get("uid") != fakeUid || r.URL.Query().Get("vei") != f.vei {
+		writeJSON(w, map[string]any{"zt": 0, "info": "非法请求"})
+		return
+	}
+	if f.busy {
+		writeJSON(w, map[string]any{"zt": 4, "info": "", "text": nil})
+		return
+	}
+
+	form := r.PostForm.Get
+	ok := func(info string) { writeJSON(w, map[string]any{"zt": 1, "info": info, "text": nil}) }
+	fail := func(info string) { writeJSON(w, map[string]any{"zt": 0, "info": info, "text": nil}) }
+
+	switch task {
+	case "47":
+		var list []map[string]any
+		for _, folder := range f.sortedFolders() {
+			if folder.Parent == form("folder_id") && folder.ID != "-1" {
+				list = append(list, map[string]any{"name": folder.Name, "fol_id": folder.ID, "folder_des": folder.Desc, "onof": onof(folder.Pwd)})
+			}
+		}
+		writeJSON(w, map[string]any{"zt": 1, "info": map[string]any{"folderid": form("folder_id")}, "text": list})
+	case "5":
+		var list []map[string]any
+		for _, file := range f.sortedFiles() {
+			if file.Folder == form("folder_id") {
+				list = append(list, map[string]any{"id": file.ID, "name_all": file.Name, "name": file.Name, "size": fakeSize(file.Data), "time": "2024-01-02", "downs": "0", "onof": onof(file.Pwd)})
+			}
+		}
+		pg, _ := strconv.Atoi(form("pg"))
+		writeJSON(w, map[string]any{"zt": 1, "info": 1, "text": fakePage(list, pg)})
+	case "2":
+		if _, ok := f.folders[form("parent_id")]; !ok {
+			fail("上级文件夹不存在")
+			return
+		}
+		id := f.newID()
+		f.folders[id] = &fakeFolder{ID: id, Parent: form("parent_id"), Name: form("folder_name"), Desc: form("folder_description")}
+		writeJSON(w, map[string]any{"zt": 1, "info": "创建成功", "text": id})
+	case "3":
+		for _, folder := range f.folders {
+			if folder.Parent == form("folder_id") {
+				fail("删除失败，文件夹中还有文件夹")
+				return
+			}
+		}
+		for _, file := range f.files {
+			if file.Folder == form("folder_id") {
+				fail("删除失败，文件夹中还有文件")
+				return
+			}
+		}
+		delete(f.folders, form("folder_id"))
+		ok("删除成功")
+	case "4":
+		folder, exist := f.folders[form("folder_id")]
+		if !exist {
+			fail("文件夹不存在")
+			return
+		}
+		folder.Name, folder.Desc = form("folder_name"), form("folder_description")
+		ok("修改成功")
+	case "6":
+		if _, exist := f.files[form("file_id")]; !exist {
+			fail("文件不存在")
+			return
+		}
+		delete(f.files, form("file_id"))
+		ok("已删除")
+	case "16", "23":
+		pwd := ""
+		if form("shows") == "1" {
+			pwd = form("shownames")
+		}
+		if folder, exist := f.folders[form("folder_id")]; task == "16" && exist {
+			folder.Pwd = pwd
+		} else if file, exist := f.files[form("file_id")]; task == "23" && exist {
+			file.Pwd = pwd
+		} else {
+			fail("文件不存在")
+			return
+		}
+		ok("设置成功")
+	case "18":
+		folder, exist := f.folders[form("file_id")]
+		if !exist {
+			fail("文件夹不存在")
+			return
+		}
+		writeJSON(w, map[string]any{"zt": 1, "info": map[string]any{
+			"name": folder.Name, "des": folder.Desc, "pwd": folder.Pwd, "onof": onof(folder.Pwd),
+			"taoc": "", "is_newd": f.srv.URL, "new_url": f.srv.URL + "/b" + folder.ID,
+		}, "text": nil})
+	case "20":
+		file, exist := f.files[form("file_id")]
+		if _, ok := f.folders[form("folder_id")]; !exist || !ok {
+			fail("文件不存在")
+			return
+		}
+		file.Folder = form("folder_id")
+		ok("移动成功")
+	case "22":
+		file, exist := f.files[form("file_id")]
+		if !exist {
+			fail("文件不存在")
+			return
+		}
+		writeJSON(w, map[string]any{"zt": 1, "info": map[string]any{
+			"pwd": file.Pwd, "onof": onof(file.Pwd), "f_id": "i" + file.ID, "taoc": "", "is_newd": f.srv.URL,
+		}, "text": nil})
+	case "46":
+		file, exist := f.files[form("file_id")]
+		if !exist {
+			fail("文件不存在")
+			return
+		}
+		file.Name = form("file_name")
+		ok("修改成功")
+	default:
+		fail("非法请求")
+	}
+}
+
+func (f *fakeLanZou) handleUpload(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	loggedIn := f.loggedIn(r)
+	f.calls["html5up"]++
+	f.mu.Unlock()
+	if !loggedIn {
+		writeJSON(w, map[string]any{"zt": 9, "info": "登录信息已失效"})
+		return
+	}
+
+	file, header, err := r.FormFile("upload_file")
+	if err != nil {
+		writeJSON(w, map[string]any{"zt": 0, "info": "上传失败"})
+		return
+	}
+	data, _ := io.ReadAll(file)
+	name := r.FormValue("name")
+	if !IsAllowedExt(name) || header.Filename != name {
+		writeJSON(w, map[string]any{"zt": 0, "info": "不允许上传的文件格式"})
+		return
+	}
+	id := f.addFile(r.FormValue("folder_id_bb_n"), name, "", string(data))
+	writeJSON(w, map[string]any{"zt": 1, "info": "上传成功", "text": []map[string]any{{
+		"icon": "zip", "id": id, "f_id": "i" + id, "name_all": name, "name": name,
+		"size": fakeSize(data), "time": "0 秒前", "downs": "0", "onof": "0", "is_newd": f.srv.URL,
+	}}})
+}
+
+// 分享页面,b 开头为文件夹,i 开头为文件
+func (f *fakeLanZou) handleSharePage(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["share"]++
+
+	switch {
+	case strings.HasPrefix(id, "b"):
+		folder, ok := f.folders[id[1:]]
+		if !ok {
+			fmt.Fprint(w, `<html><head><title>文件</title></head><body><div class="off"><div class="off0"><div class="off1"></div></div>文件不存在，或已删除</div></body></html>`)
+			return
+		}
+		fmt.Fprint(w, f.folderPage(folder))
+	case strings.HasPrefix(id, "i"):
+		file, ok := f.files[id[1:]]
+		if !ok {
+			fmt.Fprint(w, `<html><head><title>文件</title></head><body><div class="off"><div class="off0"><div class="off1"></div></div>来晚啦...文件取消分享了</div></body></html>`)
+			return
+		}
+		fmt.Fprint(w, f.filePage(file))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeLanZou) folderPage(folder *fakeFolder) string {
+	var subs strings.Builder
+	for _, sub := range f.sortedFolders() {
+		if sub.Parent == folder.ID {
+			fmt.Fprintf(&subs, `<div class="mbxfolder"><a href="/b%s" class="mlink minPx-top"><div class="filename">%s<div class="filesize"></div></div></a></div>`, sub.ID, html.EscapeString(sub.Name))
+		}
+	}
+	pwdForm := ""
+	if folder.Pwd != "" {
+		pwdForm = `<div id="pwdload" class="pwdload"><input type="text" name="pwd" class="input" id="pwd" placeholder="输入密码"><div class="passwddiv-btn" id="sub">提交</div></div>`
+	}
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html><head><title>%[1]s</title></head><body>
+<div class="pc-folderlink">%[2]s</div>
+%[3]s
+<div id="infos"><div id="name">%[1]s</div><div id="filename">说明：%[4]s</div></div>
+<script type="text/javascript">
+	var pgs;
+	var ib3k = '1700000000';
+	var _h7g = 'K%[5]s';
+	pgs =1;
+	function more(){
+		var pwd = document.getElementById('pwd') ? document.getElementById('pwd').value : '';
+		$.ajax({
+			type : 'post',
+			url : '/filemoreajax.php?file=%[5]s',
+			data : { 'lx':2,'fid':%[5]s,'uid':'%[6]s','pg':pgs,'rep':'0','t':ib3k,'k':_h7g,'up':1,'ls':1,'pwd':pwd },
+			dataType : 'json'
+		});
+	}
+</script></body></html>`, html.EscapeString(folder.Name), subs.String(), pwdForm, html.EscapeString(folder.Desc), folder.ID, fakeUid)
+}
+
+func (f *fakeLanZou) filePage(file *fakeFile) string {
+	if file.Pwd != "" {
+		return fmt.Sprintf(`<!DOCTYPE html>
+<html><head><title>文件</title></head><body>
+<div class="passwddiv"><div class="passwddiv-user">请输入密码</div><input type="text" id="pwd" class="passwdinput"><div class="passwddiv-btn" id="sub" onclick="down_p();">提交</div></div>
+<div class="n_box_3fn" id="file"><span class="n_file_infos">%[2]s</span><span class="n_file_infos">大小：%[3]s</span></div>
+<script type="text/javascript">
+	function down_p(){
+		var pwd = document.getElementById('pwd').value;
+		var skdklds = 'S%[1]s';
+		$.ajax({
+			type : 'post',
+			url : '/ajaxm.php?file=%[1]s',
+			data : { 'action':'downprocess','sign':skdklds,'p':pwd,'kd':1 },
+			dataType : 'json'
+		});
+	}
+</script></body></html>`, file.ID, "2024-01-02", fakeSize(file.Data))
+	}
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html><head><title>%[2]s - 蓝奏云</title></head><body>
+<div class="d"><div style="font-size: 30px;text-align: center;padding: 56px 0px 20px 0px;">%[2]s</div>
+<div class="d2"><table width="100%%"><tr><td>
+<span class="p7">文件大小：</span>%[3]s<br>
+<span class="p7">上传时间：</span>2024-01-02<br>
+<span class="p7">分享用户：</span><font>tester</font><br>
+<span class="p7">文件描述：</span><br>
+</td></tr></table></div>
+<iframe class="ifr2" name="1" src="/fn?F%[1]s" frameborder="0" scrolling="no"></iframe>
+</div></body></html>`, file.ID, html.EscapeString(file.Name), fakeSize(file.Data))
+}
+
+// 下载页面,提供 ajaxm 参数
+func (f *fakeLanZou) handleFn(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.RawQuery, "F")
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html><head><title></title></head><body>
+<script type="text/javascript">
+	var ajaxdata = '?ctdf';
+	var wp_sign = 'S%[1]s';
+	var aihidcms = '';
+	function down_r(k){
+		$.ajax({
+			type : 'post',
+			url : '/ajaxm.php?file=%[1]s',
+			data : { 'action':'downprocess','websignkey':ajaxdata,'signs':ajaxdata,'sign':wp_sign,'websign':aihidcms,'kd':1,'ves':1 },
+			dataType : 'json'
+		});
+	}
+</script></body></html>`, id)
+}
+
+func (f *fakeLanZou) handleFileMore(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["filemoreajax"]++
+
+	form := r.PostForm.Get
+	folder, ok := f.folders[form("fid")]
+	if !ok || form("t") != "1700000000" || form("k") != "K"+folder.ID {
+		writeJSON(w, map[string]any{"zt": 0, "info": "非法请求", "text": nil})
+		return
+	}
+	if folder.Pwd != "" && form("pwd") != folder.Pwd {
+		writeJSON(w, map[string]any{"zt": 3, "info": "密码不正确", "text": nil})
+		return
+	}
+
+	var list []map[string]any
+	for _, file := range f.sortedFiles() {
+		if file.Folder == folder.ID {
+			list = append(list, map[string]any{"icon": "zip", "t": 0, "id": "i" + file.ID, "name_all": file.Name, "size": fakeSize(file.Data), "time": "2024-01-02", "duan": "i" + file.ID, "p_ico": 0})
+		}
+	}
+	pg, _ := strconv.Atoi(form("pg"))
+	if page := fakePage(list, pg); len(page) > 0 {
+		writeJSON(w, map[string]any{"zt": 1, "info": "sucess", "text": page})
+		return
+	}
+	writeJSON(w, map[string]any{"zt": 2, "info": "没有了", "text": []any{}})
+}
+
+func (f *fakeLanZou) handleAjaxm(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls["ajaxm"]++
+
+	file, ok := f.files[r.URL.Query().Get("file")]
+	if !ok || r.PostForm.Get("sign") != "S"+file.ID {
+		writeJSON(w, map[string]any{"zt": 0, "dom": "", "url": 0, "inf": "sign 错误"})
+		return
+	}
+	if file.Pwd == "" {
+		writeJSON(w, map[string]any{"zt": 1, "dom": f.srv.URL, "url": "?D" + file.ID, "inf": 0})
+		return
+	}
+	if r.PostForm.Get("p") != file.Pwd {
+		writeJSON(w, map[string]any{"zt": 0, "dom": "", "url": "0", "inf": "密码不正确"})
+		return
+	}
+	writeJSON(w, map[string]any{"zt": 1, "dom": f.srv.URL, "url": "?D" + file.ID, "inf": file.Name})
+}
+
+// 下载链接重定向到带过期时间的真实地址
+func (f *fakeLanZou) handleRedirect(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.RawQuery, "D")
+	exp := time.Now().Add(10 * time.Minute).Unix()
+	http.Redirect(w, r, fmt.Sprintf("%s/down/%s?e=%d", f.srv.URL, id, exp), http.StatusFound)
+}
+
+func (f *fakeLanZou) handleDownload(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	file, ok := f.files[strings.TrimPrefix(r.URL.Path, "/down/")]
+	f.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Last-Modified", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Format(http.TimeFormat))
+	http.ServeContent(w, r, file.Name, time.Time{}, strings.NewReader(string(file.Data)))
+}
+
+func (f *fakeLanZou) sortedFolders() []*fakeFolder {
+	folders := make([]*fakeFolder, 0, len(f.folders))
+	for _, folder := range f.folders {
+		folders = append(folders, folder)
+	}
+	sort.Slice(folders, func(i, j int) bool { return folders[i].ID < folders[j].ID })
+	return folders
+}
+
+func (f *fakeLanZou) sortedFiles() []*fakeFile {
+	files := make([]*fakeFile, 0, len(f.files))
+	for _, file := range f.files {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ID < files[j].ID })
+	return files
+}
+
+func fakePage[T any](list []T, pg int) []T {
+	start := (pg - 1) * fakePageSize
+	if pg < 1 || start >= len(list) {
+		return []T{}
+	}
+	return list[start:min(start+fakePageSize, len(list))]
+}
+
+func fakeSize(data []byte) string {
+	return fmt.Sprintf("%d B", len(data))
+}
+
+func onof(pwd string) string {
+	if pwd == "" {
+		return "0"
+	}
+	return "1"
+}
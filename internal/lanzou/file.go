@@ -0,0 +1,335 @@
+package lanzou
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/tidwall/gjson"
+	"resty.dev/v3"
+)
+
+/*
+通过cookie获取数据
+*/
+
+// 通过ID获取文件夹
+func (c *Client) GetFolders(folderID string) ([]FileOrFolder, error) {
+	var resp RespText[[]FileOrFolder]
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetFormData(map[string]string{
+			"task":      "47",
+			"folder_id": folderID,
+		})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Text, nil
+}
+
+// 通过ID获取文件
+func (c *Client) GetFiles(folderID string) ([]FileOrFolder, error) {
+	files := make([]FileOrFolder, 0)
+	for pg := 1; ; pg++ {
+		var resp RespText[[]FileOrFolder]
+		_, err := c.Doupload(func(req *resty.Request) {
+			req.SetFormData(map[string]string{
+				"task":      "5",
+				"folder_id": folderID,
+				"pg":        strconv.Itoa(pg),
+			})
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Text) == 0 {
+			break
+		}
+		files = append(files, resp.Text...)
+	}
+	return files, nil
+}
+
+// 通过ID获取文件夹分享地址
+func (c *Client) GetFolderShareUrlByID(fileID string) (*FileShare, error) {
+	var resp RespInfo[FileShare]
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetFormData(map[string]string{
+			"task":    "18",
+			"file_id": fileID,
+		})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Info, nil
+}
+
+// 通过ID获取文件分享地址
+func (c *Client) GetFileShareUrlByID(fileID string) (*FileShare, error) {
+	var resp RespInfo[FileShare]
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetFormData(map[string]string{
+			"task":    "22",
+			"file_id": fileID,
+		})
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp.Info, nil
+}
+
+// 通过下载头获取真实文件信息
+func (c *Client) GetFileRealInfo(downURL string) (int64, time.Time) {
+	res, _ := c.Client.R().Head(downURL)
+	if res == nil {
+		return 0, time.Time{}
+	}
+	time, _ := http.ParseTime(res.Header().Get("Last-Modified"))
+	size, _ := strconv.ParseInt(res.Header().Get("Content-Length"), 10, 64)
+	return size, time
+}
+
+// 新建文件夹,返回新文件夹ID
+func (c *Client) MakeDir(ctx context.Context, parentID, name, description string) (string, error) {
+	data, err := c.Doupload(func(req *resty.Request) {
+		req.SetContext(ctx)
+		req.SetFormData(map[string]string{
+			"task":               "2",
+			"parent_id":          parentID,
+			"folder_name":        name,
+			"folder_description": description,
+		})
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	return gjson.GetBytes(data, "text").String(), nil
+}
+
+// 修改文件夹名称和描述,描述会被一同覆盖
+func (c *Client) EditFolder(ctx context.Context, folderID, name, description string) error {
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetContext(ctx)
+		req.SetFormData(map[string]string{
+			"task":               "4",
+			"folder_id":          folderID,
+			"folder_name":        name,
+			"folder_description": description,
+		})
+	}, nil)
+	return err
+}
+
+// 移动文件
+func (c *Client) MoveFile(ctx context.Context, fileID, folderID string) error {
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetContext(ctx)
+		req.SetFormData(map[string]string{
+			"task":      "20",
+			"folder_id": folderID,
+			"file_id":   fileID,
+		})
+	}, nil)
+	return err
+}
+
+// 删除文件
+func (c *Client) RemoveFile(ctx context.Context, fileID string) error {
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetContext(ctx)
+		req.SetFormData(map[string]string{
+			"task":    "6",
+			"file_id": fileID,
+		})
+	}, nil)
+	return err
+}
+
+// 重命名文件
+func (c *Client) RenameFile(ctx context.Context, fileID, name string) error {
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetContext(ctx)
+		req.SetFormData(map[string]string{
+			"task":      "46",
+			"file_id":   fileID,
+			"file_name": name,
+			"type":      "2",
+		})
+	}, nil)
+	return err
+}
+
+// 删除文件夹
+func (c *Client) RemoveFolder(ctx context.Context, folderID string) error {
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetContext(ctx)
+		req.SetFormData(map[string]string{
+			"task":      "3",
+			"folder_id": folderID,
+		})
+	}, nil)
+	return err
+}
+
+// 蓝奏云没有移动文件夹的接口,只能在目标位置重建目录后逐个移动文件
+// 重建的文件夹保留原描述和提取码
+// 记录已完成的步骤,失败时按相反顺序回滚
+type folderMover struct {
+	c   *Client
+	ctx context.Context
+
+	moved   [][2]string // [文件ID, 原文件夹ID]
+	created []string    // 新建的文件夹
+	emptied []string    // 已清空的源文件夹,全部完成后再删除
+}
+
+// 移动文件夹,返回新文件夹ID
+func (c *Client) MoveFolder(ctx context.Context, srcID, name, dstID string) (string, error) {
+	if err := c.CheckMoveTarget(srcID, dstID); err != nil {
+		return "", err
+	}
+
+	m := &folderMover{c: c, ctx: ctx}
+	newID, err := m.move(srcID, name, dstID)
+	if err != nil {
+		if rerr := m.rollback(); rerr != nil {
+			return "", fmt.Errorf("move folder %s failed after moving %d files, rollback failed: %w", name, len(m.moved), errors.Join(err, rerr))
+		}
+		return "", err
+	}
+
+	// 后序记录,子文件夹在前
+	for _, folderID := range m.emptied {
+		if err := c.RemoveFolder(ctx, folderID); err != nil {
+			return "", fmt.Errorf("move folder %s: all files moved, but remove source folder failed: %w", name, err)
+		}
+	}
+	return newID, nil
+}
+
+// 目标不能是源文件夹或其子文件夹,否则移动会重建到正在清空的目录中,复制不会结束
+func (c *Client) CheckMoveTarget(srcID, dstID string) error {
+	if srcID == dstID {
+		return ErrMoveIntoSubfolder
+	}
+	folders, err := c.GetFolders(srcID)
+	if err != nil {
+		return err
+	}
+	for _, folder := range folders {
+		if err := c.CheckMoveTarget(folder.GetID(), dstID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *folderMover) move(srcID, name, dstID string) (string, error) {
+	share, err := m.c.GetFolderShareUrlByID(srcID)
+	if err != nil {
+		return "", err
+	}
+	newID, err := m.c.MakeDir(m.ctx, dstID, name, share.Des)
+	if err != nil {
+		return "", err
+	}
+	m.created = append(m.created, newID)
+	if share.Onof == "1" && share.Pwd != "" {
+		if err := m.c.SetFolderPassword(m.ctx, newID, share.Pwd, true); err != nil {
+			return "", err
+		}
+	}
+
+	files, err := m.c.GetFiles(srcID)
+	if err != nil {
+		return "", err
+	}
+	for _, file := range files {
+		if err := m.c.MoveFile(m.ctx, file.GetID(), newID); err != nil {
+			return "", err
+		}
+		m.moved = append(m.moved, [2]string{file.GetID(), srcID})
+	}
+
+	folders, err := m.c.GetFolders(srcID)
+	if err != nil {
+		return "", err
+	}
+	for _, folder := range folders {
+		if _, err := m.move(folder.GetID(), folder.GetName(), newID); err != nil {
+			return "", err
+		}
+	}
+
+	m.emptied = append(m.emptied, srcID)
+	return newID, nil
+}
+
+// 将已移动的文件移回原处并删除新建的文件夹
+func (m *folderMover) rollback() error {
+	// 原请求可能已被取消,回滚不受其影响
+	ctx := context.WithoutCancel(m.ctx)
+	for i := len(m.moved) - 1; i >= 0; i-- {
+		if err := m.c.MoveFile(ctx, m.moved[i][0], m.moved[i][1]); err != nil {
+			return fmt.Errorf("%d files left in destination: %w", i+1, err)
+		}
+	}
+	for i := len(m.created) - 1; i >= 0; i-- {
+		if err := m.c.RemoveFolder(ctx, m.created[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 上传文件,name 为上传后的文件名
+func (c *Client) Upload(ctx context.Context, folderID, name string, reader io.Reader) (*FileOrFolder, error) {
+	var resp RespText[[]FileOrFolder]
+	_, err := c.Request(http.MethodPost, MustUrlJoin(c.BaseUrl, "/html5up.php"), func(client *resty.Request) {
+		client.SetContext(ctx).
+			SetMultipartFormData(map[string]string{
+				"task":           "1",
+				"vie":            "2",
+				"ve":             "2",
+				"id":             "WU_FILE_0",
+				"name":           name,
+				"folder_id_bb_n": folderID,
+			}).
+			SetFileReader("upload_file", name, reader)
+	}, &resp, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Text) == 0 {
+		return nil, errors.New("upload response is empty")
+	}
+	return &resp.Text[0], nil
+}
+
+// 设置文件夹提取码,on 为 false 时关闭提取码
+func (c *Client) SetFolderPassword(ctx context.Context, folderID, pwd string, on bool) error {
+	_, err := c.Doupload(func(req *resty.Request) {
+		req.SetContext(ctx)
+		req.SetFormData(map[string]string{
+			"task":      "16",
+			"folder_id": folderID,
+			"shows":     shareShows(on),
+			"shownames": pwd,
+		})
+	}, nil)
+	return err
+}
+
+func shareShows(on bool) string {
+	if on {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,210 @@
+package lanzou
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func fileNames(files []FileOrFolder) []string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.GetName())
+	}
+	return names
+}
+
+func TestListFiles(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	dir := f.addFolder("-1", "docs", "")
+	f.addFolder(dir, "sub", "")
+	for _, name := range []string{"a.zip", "b.zip", "c.zip"} {
+		f.addFile(dir, name, "", "data")
+	}
+
+	folders, err := c.GetFolders(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 1 || !folders[0].IsDir() || folders[0].GetName() != "sub" {
+		t.Fatalf("folders = %+v", folders)
+	}
+
+	// 超过一页时翻页获取
+	files, err := c.GetFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fileNames(files), ","); got != "a.zip,b.zip,c.zip" {
+		t.Fatalf("files = %s", got)
+	}
+	if files[0].GetSize() != 4 {
+		t.Fatalf("size = %d", files[0].GetSize())
+	}
+}
+
+func TestFileOperations(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeLanZou(t)
+	c := f.login(t)
+
+	dir, err := c.MakeDir(ctx, "-1", "docs", "my docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := c.Upload(ctx, "-1", "a.zip", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.GetName() != "a.zip" || file.GetID() == "" {
+		t.Fatalf("uploaded = %+v", file)
+	}
+
+	if err := c.RenameFile(ctx, file.GetID(), "b.zip"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.MoveFile(ctx, file.GetID(), dir); err != nil {
+		t.Fatal(err)
+	}
+	files, err := c.GetFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fileNames(files), ","); got != "b.zip" {
+		t.Fatalf("files in %s = %s", dir, got)
+	}
+
+	share, err := c.GetFolderShareUrlByID(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share.Des != "my docs" {
+		t.Fatalf("folder description = %q", share.Des)
+	}
+	if err := c.EditFolder(ctx, dir, "papers", share.Des); err != nil {
+		t.Fatal(err)
+	}
+
+	// 非空文件夹不能删除
+	if err := c.RemoveFolder(ctx, dir); err == nil {
+		t.Fatal("remove non-empty folder succeeded")
+	}
+	if err := c.RemoveFile(ctx, file.GetID()); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RemoveFolder(ctx, dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RemoveFile(ctx, file.GetID()); !errors.Is(err, ErrFileNotExist) {
+		t.Fatalf("remove twice: got %v, want ErrFileNotExist", err)
+	}
+}
+
+func TestUploadRejectedType(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	_, err := c.Upload(context.Background(), "-1", "a.mkv", strings.NewReader("data"))
+	if !errors.Is(err, ErrFileTypeRejected) {
+		t.Fatalf("got %v, want ErrFileTypeRejected", err)
+	}
+	if _, err := c.Upload(context.Background(), "-1", WrapName("a.mkv", "zip"), strings.NewReader("data")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMoveFolder(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	src := f.addFolder("-1", "src", "")
+	f.folders[src].Desc = "my docs"
+	sub := f.addFolder(src, "sub", "1234")
+	f.addFile(src, "a.zip", "", "a")
+	f.addFile(sub, "b.zip", "", "b")
+	dst := f.addFolder("-1", "dst", "")
+
+	newID, err := c.MoveFolder(ctx, src, "src", dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := f.folders[src]; ok {
+		t.Fatal("source folder not removed")
+	}
+	if _, ok := f.folders[sub]; ok {
+		t.Fatal("source subfolder not removed")
+	}
+	folders, err := c.GetFolders(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(folders) != 1 || folders[0].GetID() != newID || folders[0].GetName() != "src" {
+		t.Fatalf("folders in dst = %+v", folders)
+	}
+	files, err := c.GetFiles(newID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(fileNames(files), ","); got != "a.zip" {
+		t.Fatalf("files in moved folder = %s", got)
+	}
+	subs, err := c.GetFolders(newID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 1 {
+		t.Fatalf("subfolders in moved folder = %+v", subs)
+	}
+	if files, _ := c.GetFiles(subs[0].GetID()); len(files) != 1 || files[0].GetName() != "b.zip" {
+		t.Fatalf("files in moved subfolder = %+v", files)
+	}
+
+	// 描述和提取码随文件夹移动
+	if folder := f.folders[newID]; folder.Desc != "my docs" || folder.Pwd != "" {
+		t.Fatalf("moved folder desc %q, pwd %q", folder.Desc, folder.Pwd)
+	}
+	if folder := f.folders[subs[0].GetID()]; folder.Pwd != "1234" {
+		t.Fatalf("moved subfolder pwd %q", folder.Pwd)
+	}
+}
+
+func TestMoveFolderIntoSubfolder(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	src := f.addFolder("-1", "src", "")
+	sub := f.addFolder(src, "sub", "")
+	deep := f.addFolder(sub, "deep", "")
+	f.addFile(src, "a.zip", "", "a")
+
+	for _, dst := range []string{src, sub, deep} {
+		_, err := c.MoveFolder(ctx, src, "src", dst)
+		if !errors.Is(err, ErrMoveIntoSubfolder) || !errors.Is(err, ErrNotSupported) {
+			t.Fatalf("move into %s: got %v, want ErrMoveIntoSubfolder", dst, err)
+		}
+	}
+	if len(f.folders) != 4 || f.calledTimes("doupload:2") != 0 {
+		t.Fatalf("folders %d, task 2 called %d times", len(f.folders), f.calledTimes("doupload:2"))
+	}
+}
+
+func TestMoveFolderRollback(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	src := f.addFolder("-1", "src", "")
+	file := f.addFile(src, "a.zip", "", "a")
+
+	// 目标文件夹不存在,新建失败后不应有残留
+	if _, err := c.MoveFolder(ctx, src, "src", "999"); err == nil {
+		t.Fatal("move into missing folder succeeded")
+	}
+	if f.files[file].Folder != src {
+		t.Fatal("file not kept in source folder")
+	}
+	if len(f.folders) != 2 {
+		t.Fatalf("folders after rollback = %d", len(f.folders))
+	}
+}
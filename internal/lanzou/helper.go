@@ -1,8 +1,6 @@
-package main
+package lanzou
 
 import (
-	"encoding/hex"
-	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -118,64 +116,18 @@ func RemoveJSComment(data string) string {
 	return result.String()
 }
 
-var findAcwScV2Reg = regexp.MustCompile(`arg1='([0-9A-Z]+)'`)
-
-// 在页面被过多访问或其他情况下，有时候会先返回一个加密的页面，其执行计算出一个acw_sc__v2后放入页面后再重新访问页面才能获得正常页面
-// 若该页面进行了js加密，则进行解密，计算acw_sc__v2，并加入cookie
-func CalcAcwScV2(htmlContent string) (string, error) {
-	matches := findAcwScV2Reg.FindStringSubmatch(htmlContent)
-	if len(matches) != 2 {
-		return "", errors.New("无法匹配到 arg1 参数")
-	}
-	arg1 := matches[1]
-
-	mask := "3000176000856006061501533003690027800375"
-	result, err := hexXor(unbox(arg1), mask)
-	if err != nil {
-		return "", fmt.Errorf("hexXor 操作失败: %w", err)
-	}
-
-	return result, nil
-}
-
-func unbox(hex string) string {
-	var box = []int{6, 28, 34, 31, 33, 18, 30, 23, 9, 8, 19, 38, 17, 24, 0, 5, 32, 21, 10, 22, 25, 14, 15, 3, 16, 27, 13, 35, 2, 29, 11, 26, 4, 36, 1, 39, 37, 7, 20, 12}
-	var newBox = make([]byte, len(hex))
-	for i, j := range box {
-		if len(newBox) > j {
-			newBox[j] = hex[i]
-		}
-	}
-	return string(newBox)
-}
-
-func hexXor(hex1, hex2 string) (string, error) {
-	bytes1, err := hex.DecodeString(hex1)
-	if err != nil {
-		return "", fmt.Errorf("解码 hex1 失败: %w", err)
-	}
-	bytes2, err := hex.DecodeString(hex2)
-	if err != nil {
-		return "", fmt.Errorf("解码 hex2 失败: %w", err)
-	}
-	minLength := min(len(bytes2), len(bytes1))
-	resultBytes := make([]byte, minLength)
-	for i := range minLength {
-		resultBytes[i] = bytes1[i] ^ bytes2[i]
-	}
-	return hex.EncodeToString(resultBytes), nil
-}
-
 var findDataReg = regexp.MustCompile(`data[:\s]+({[^}]+})`)    // 查找json
 var findKVReg = regexp.MustCompile(`'(.+?)':('?([^' },]*)'?)`) // 拆分kv
 
-// 根据key查询js变量
+// 根据key查询js变量,仅在 JS 求值失败时使用
 func findJSVarFunc(key, data string) string {
+	// key 来自页面,需要转义
+	reg := regexp.MustCompile(`var ` + regexp.QuoteMeta(key) + `\s*=\s*['"]?(.+?)['"]?;`)
 	var values []string
 	if key != "sasign" {
-		values = regexp.MustCompile(`var ` + key + `\s*=\s*['"]?(.+?)['"]?;`).FindStringSubmatch(data)
+		values = reg.FindStringSubmatch(data)
 	} else {
-		matches := regexp.MustCompile(`var `+key+`\s*=\s*['"]?(.+?)['"]?;`).FindAllStringSubmatch(data, -1)
+		matches := reg.FindAllStringSubmatch(data, -1)
 		if len(matches) == 3 {
 			values = matches[1]
 		} else {
@@ -249,36 +201,37 @@ func getJSFunctionByName(html string, name string) (string, error) {
 			return data, nil
 		}
 	}
-	return "", fmt.Errorf("getJSFunctionByName: not find %s function", name)
+	return "", fmt.Errorf("%w: getJSFunctionByName: not find %s function", ErrTemplateChanged, name)
 }
 
 // 解析html中的JSON,选择最长的数据
 func htmlJsonToMap2(html string) (map[string]string, error) {
-	datas := findDataReg.FindAllStringSubmatch(html, -1)
-	var sData string
+	datas := findDataReg.FindAllStringSubmatchIndex(html, -1)
+	var sData []int
 	for _, data := range datas {
-		if len(datas) > 0 && len(data[1]) > len(sData) {
-			sData = data[1]
+		if sData == nil || data[3]-data[2] > sData[3]-sData[2] {
+			sData = data
 		}
 	}
-	if sData == "" {
-		return nil, fmt.Errorf("htmlJsonToMap2: not find data")
+	if sData == nil {
+		return nil, fmt.Errorf("%w: htmlJsonToMap2: not find data", ErrTemplateChanged)
 	}
-	return jsonToMap(sData, html), nil
+	return jsonToMap(html, sData[2], sData[3]), nil
 }
 
 // 解析html中的JSON
 func htmlJsonToMap(html string) (map[string]string, error) {
-	datas := findDataReg.FindStringSubmatch(html)
-	if len(datas) != 2 {
-		return nil, fmt.Errorf("htmlJsonToMap: not find data")
+	datas := findDataReg.FindStringSubmatchIndex(html)
+	if len(datas) != 4 {
+		return nil, fmt.Errorf("%w: htmlJsonToMap: not find data", ErrTemplateChanged)
 	}
-	return jsonToMap(datas[1], html), nil
+	return jsonToMap(html, datas[2], datas[3]), nil
 }
 
-func jsonToMap(data, html string) map[string]string {
+// 解析 html[start:end] 中的对象,优先使用 JS 求值,求值失败的字段使用正则兜底
+func jsonToMap(html string, start, end int) map[string]string {
 	var param = make(map[string]string)
-	kvs := findKVReg.FindAllStringSubmatch(data, -1)
+	kvs := findKVReg.FindAllStringSubmatch(html[start:end], -1)
 	for _, kv := range kvs {
 		k, v := kv[1], kv[3]
 		if v == "" || strings.Contains(kv[2], "'") || IsNumber(kv[2]) {
@@ -287,6 +240,18 @@ func jsonToMap(data, html string) map[string]string {
 			param[k] = findJSVarFunc(v, html)
 		}
 	}
+
+	obj, err := EvalJSObjectAt(html, start)
+	if err != nil {
+		return param
+	}
+	for k, v := range obj {
+		switch v.(type) {
+		case nil, jsNull, map[string]any, []any, *jsFunc:
+		default:
+			param[k] = jsToString(v)
+		}
+	}
 	return param
 }
 
@@ -305,7 +270,7 @@ var findFromReg = regexp.MustCompile(`data : '(.+?)'`) // 查找from字符串
 func htmlFormToMap(html string) (map[string]string, error) {
 	forms := findFromReg.FindStringSubmatch(html)
 	if len(forms) != 2 {
-		return nil, fmt.Errorf("not find file sgin")
+		return nil, fmt.Errorf("%w: not find file sgin", ErrTemplateChanged)
 	}
 	return formToMap(forms[1]), nil
 }
@@ -382,15 +347,96 @@ func joinURL(base, path string) string {
 	return fmt.Sprintf("%s/%s", trimmedBase, trimmedPath)
 }
 
+// 带域名的 cookie 保存到对应域名下,其余保存到 url_ 的域名下
 func SetCookieToJar(jar http.CookieJar, url_ string, cookies []*http.Cookie) error {
 	u, err := url.Parse(url_)
 	if err != nil {
 		return err
 	}
 	for _, cookie := range cookies {
-		cookie.Domain = u.Host
-		cookie.Path = "/"
+		if cookie.Path == "" {
+			cookie.Path = "/"
+		}
+		if cookie.Domain == "" {
+			cookie.Domain = u.Host
+			jar.SetCookies(u, []*http.Cookie{cookie})
+			continue
+		}
+		// 仅限当前域名的 cookie 不设置 Domain 属性
+		host := strings.TrimPrefix(cookie.Domain, ".")
+		if !strings.HasPrefix(cookie.Domain, ".") {
+			cookie.Domain = ""
+		}
+		jar.SetCookies(&url.URL{Scheme: u.Scheme, Host: host, Path: cookie.Path}, []*http.Cookie{cookie})
 	}
-	jar.SetCookies(u, cookies)
 	return nil
 }
+
+// 蓝奏云允许上传的文件类型
+var allowedExts = map[string]bool{}
+
+func init() {
+	for _, ext := range strings.Split("doc,docx,zip,rar,apk,ipa,txt,exe,7z,e,z,ct,ke,cetrainer,db,tar,pdf,w3x,epub,mobi,azw,azw3,osk,osz,xpa,cpk,lua,jar,dmg,ppt,pptx,xls,xlsx,mp3,img,gho,ttf,ttc,txf,dwg,bat,imazingapp,dll,crx,xapk,conf,deb,rp,rpm,rplib,mobileconfig,appimage,lolgezi,flac,cad,hwt,accdb,ce,xmind,enc,bds,bdi,ssf,it,pkg,cfg", ",") {
+		allowedExts[ext] = true
+	}
+}
+
+const wrapMark = ".lzwrap"
+
+// 包装后的文件名: 原文件名.lzwrap.允许的后缀
+var wrapNameReg = regexp.MustCompile(`^(.+)` + regexp.QuoteMeta(wrapMark) + `\.[0-9a-zA-Z]+$`)
+
+// 判断文件类型是否允许上传
+func IsAllowedExt(name string) bool {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return false
+	}
+	return allowedExts[strings.ToLower(name[i+1:])]
+}
+
+// 为不允许上传的文件类型加上后缀,ext 为空时不处理
+// 本身就像包装后名称的文件也要包装,否则列表时会被误还原
+func WrapName(name, ext string) string {
+	ext = strings.TrimPrefix(ext, ".")
+	if ext == "" || (IsAllowedExt(name) && !wrapNameReg.MatchString(name)) {
+		return name
+	}
+	return name + wrapMark + "." + ext
+}
+
+// 还原被包装的文件名
+func UnwrapName(name string) string {
+	if names := wrapNameReg.FindStringSubmatch(name); len(names) == 2 {
+		return names[1]
+	}
+	return name
+}
+
+// 分享链接: https://域名/分享ID 或省略协议的 xxx.lanzou*.com/分享ID,可以出现在文本任意位置
+var shareURLReg = regexp.MustCompile(`(?i)(https?://[^/\s?#]+|(?:[0-9a-z-]+\.)+lanzou[0-9a-z]*\.com)/+([0-9a-z_-]+)`)
+
+// 单独的分享ID
+var shareIDReg = regexp.MustCompile(`^([0-9a-zA-Z_-]+)/?(?:[?#]\S*)?$`)
+
+// 提取码: 密码:abcd 提取码：abcd ?pwd=abcd
+var sharePwdReg = regexp.MustCompile(`(?i)(?:密码|提取码|pwd)\s*[:：=]\s*([0-9a-zA-Z]+)`)
+
+// 解析分享链接,返回域名(可能为空)、分享ID和链接中附带的提取码,无法识别时分享ID为空
+func ParseShareLink(link string) (host, shareID, pwd string) {
+	link = strings.TrimSpace(link)
+	if links := shareURLReg.FindStringSubmatch(link); len(links) == 3 {
+		host, shareID = links[1], links[2]
+		if !strings.Contains(host, "://") {
+			host = "https://" + host
+		}
+	} else if fields := strings.Fields(link); len(fields) > 0 {
+		if ids := shareIDReg.FindStringSubmatch(fields[0]); len(ids) == 2 {
+			shareID = ids[1]
+		}
+	}
+	if pwds := sharePwdReg.FindStringSubmatch(link); len(pwds) == 2 {
+		pwd = pwds[1]
+	}
+	return
+}
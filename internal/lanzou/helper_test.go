@@ -0,0 +1,39 @@
+package lanzou
+
+import "testing"
+
+func TestWrapName(t *testing.T) {
+	tests := []struct {
+		name, ext, wrapped string
+	}{
+		{"a.zip", "zip", "a.zip"},
+		{"a.ZIP", "zip", "a.ZIP"},
+		{"disk.iso", "zip", "disk.iso.lzwrap.zip"},
+		{"a.tar.zst", ".zip", "a.tar.zst.lzwrap.zip"},
+		{"binary", "zip", "binary.lzwrap.zip"},
+		{"movie.mkv", "7z", "movie.mkv.lzwrap.7z"},
+		{"disk.iso", "", "disk.iso"},
+		// 已经像包装后名称的文件再包装一次,还原后不变
+		{"x.lzwrap.zip", "zip", "x.lzwrap.zip.lzwrap.zip"},
+		{"x.iso.lzwrap.7z", "zip", "x.iso.lzwrap.7z.lzwrap.zip"},
+		{"x.lzwrap.mkv", "zip", "x.lzwrap.mkv.lzwrap.zip"},
+	}
+	for _, tt := range tests {
+		wrapped := WrapName(tt.name, tt.ext)
+		if wrapped != tt.wrapped {
+			t.Errorf("WrapName(%q, %q) = %q, want %q", tt.name, tt.ext, wrapped, tt.wrapped)
+		}
+		if tt.ext != "" && !IsAllowedExt(wrapped) {
+			t.Errorf("wrapped name %q is not allowed", wrapped)
+		}
+		if got := UnwrapName(wrapped); got != tt.name {
+			t.Errorf("UnwrapName(%q) = %q, want %q", wrapped, got, tt.name)
+		}
+	}
+
+	for _, name := range []string{"a.zip", "lzwrap.zip", ".lzwrap.zip", "a.lzwrap", "a.lzwrap.", "a.lzwrap.z-p"} {
+		if got := UnwrapName(name); got != name {
+			t.Errorf("UnwrapName(%q) = %q", name, got)
+		}
+	}
+}
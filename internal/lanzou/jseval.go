@@ -0,0 +1,1157 @@
+package lanzou
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+/*
+JS 求值
+分享页面会通过拼接、重复赋值等方式混淆 ajax 的 data 参数
+这里实现一个只覆盖页面常见写法的受限解释器: 变量声明与赋值、字符串拼接、三元表达式、对象字面量和简单函数调用
+不支持循环和对象修改,无法识别的语句直接跳过,无法求值的表达式视为 undefined
+*/
+
+const (
+	jsMaxSteps  = 200000
+	jsMaxDepth  = 32
+	jsMaxString = 1 << 20  // 超过长度的字符串拼接结果视为 undefined
+	jsMaxAlloc  = 64 << 20 // 字符串转换和拼接累计处理的字节数
+)
+
+var errJSBudget = errors.New("js: evaluation budget exceeded")
+
+type jsTokKind uint8
+
+const (
+	jsTokIdent jsTokKind = iota
+	jsTokNum
+	jsTokStr
+	jsTokPunct
+	jsTokRegex
+)
+
+type jsTok struct {
+	kind jsTokKind
+	val  string
+	num  float64
+	pos  int
+}
+
+func (t jsTok) is(val string) bool {
+	return (t.kind == jsTokPunct || t.kind == jsTokIdent) && t.val == val
+}
+
+// null,undefined 使用 nil
+type jsNull struct{}
+
+// 函数,body 为函数体 token 范围(不含花括号)
+type jsFunc struct {
+	params  []string
+	start   int
+	end     int
+	expr    bool // 箭头函数的表达式体
+	closure *jsEnv
+	native  func(args []any) any
+}
+
+type jsEnv struct {
+	vars   map[string]any
+	parent *jsEnv
+}
+
+func newJSEnv(parent *jsEnv) *jsEnv {
+	return &jsEnv{vars: make(map[string]any), parent: parent}
+}
+
+func (e *jsEnv) get(name string) any {
+	for ; e != nil; e = e.parent {
+		if v, ok := e.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// 赋值给最近声明的作用域,未声明时作为全局变量
+func (e *jsEnv) assign(name string, v any) {
+	scope := e
+	for ; scope != nil; scope = scope.parent {
+		if _, ok := scope.vars[name]; ok {
+			scope.vars[name] = v
+			return
+		}
+		if scope.parent == nil {
+			scope.vars[name] = v
+			return
+		}
+	}
+}
+
+var jsPuncts = []string{"===", "!==", "...", "==", "!=", "<=", ">=", "&&", "||", "+=", "-=", "*=", "/=", "++", "--", "=>"}
+
+// 词法分析
+func jsLex(src string) []jsTok {
+	var toks []jsTok
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v':
+			i++
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end + 4
+			}
+		case c == '\'' || c == '"' || c == '`':
+			val, n := jsLexString(src[i:])
+			toks = append(toks, jsTok{kind: jsTokStr, val: val, pos: i})
+			i += n
+		case isJSDigit(c) || (c == '.' && i+1 < len(src) && isJSDigit(src[i+1])):
+			j := i
+			for j < len(src) && (isJSIdentPart(src[j]) || src[j] == '.') {
+				j++
+			}
+			toks = append(toks, jsTok{kind: jsTokNum, val: src[i:j], num: jsParseNumber(src[i:j]), pos: i})
+			i = j
+		case isJSIdentStart(c):
+			j := i
+			for j < len(src) && isJSIdentPart(src[j]) {
+				j++
+			}
+			toks = append(toks, jsTok{kind: jsTokIdent, val: src[i:j], pos: i})
+			i = j
+		case c == '/' && jsRegexAllowed(toks):
+			n := jsLexRegex(src[i:])
+			toks = append(toks, jsTok{kind: jsTokRegex, val: src[i : i+n], pos: i})
+			i += n
+		default:
+			punct := src[i : i+1]
+			for _, p := range jsPuncts {
+				if strings.HasPrefix(src[i:], p) {
+					punct = p
+					break
+				}
+			}
+			toks = append(toks, jsTok{kind: jsTokPunct, val: punct, pos: i})
+			i += len(punct)
+		}
+	}
+	return toks
+}
+
+func isJSDigit(c byte) bool { return c >= '0' && c <= '9' }
+func isJSIdentStart(c byte) bool {
+	return c == '_' || c == '$' || c >= 0x80 || (c|0x20 >= 'a' && c|0x20 <= 'z')
+}
+func isJSIdentPart(c byte) bool { return isJSIdentStart(c) || isJSDigit(c) }
+
+func jsParseNumber(s string) float64 {
+	if n, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return float64(n)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return math.NaN()
+}
+
+// 除号和正则的区分: 前一个 token 为值时是除号
+func jsRegexAllowed(toks []jsTok) bool {
+	if len(toks) == 0 {
+		return true
+	}
+	prev := toks[len(toks)-1]
+	switch prev.kind {
+	case jsTokPunct:
+		return prev.val != ")" && prev.val != "]"
+	case jsTokIdent:
+		return prev.val == "return" || prev.val == "typeof" || prev.val == "case"
+	}
+	return false
+}
+
+// 返回字符串的值和占用长度
+func jsLexString(src string) (string, int) {
+	quote := src[0]
+	var sb strings.Builder
+	i := 1
+	for i < len(src) && src[i] != quote {
+		c := src[i]
+		if c != '\\' || i+1 >= len(src) {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+		i++
+		switch e := src[i]; e {
+		case 'n':
+			sb.WriteByte('\n')
+		case 't':
+			sb.WriteByte('\t')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		case '0':
+			sb.WriteByte(0)
+		case 'x', 'u':
+			n := 2
+			if e == 'u' {
+				n = 4
+			}
+			if i+n < len(src) {
+				if r, err := strconv.ParseUint(src[i+1:i+1+n], 16, 32); err == nil {
+					sb.WriteRune(rune(r))
+					i += n
+					break
+				}
+			}
+			sb.WriteByte(e)
+		case '\n':
+		default:
+			sb.WriteByte(e)
+		}
+		i++
+	}
+	return sb.String(), min(i+1, len(src))
+}
+
+// 返回正则字面量的长度
+func jsLexRegex(src string) int {
+	inClass := false
+	i := 1
+	for ; i < len(src) && src[i] != '\n'; i++ {
+		switch src[i] {
+		case '\\':
+			i++
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if !inClass {
+				i++
+				for i < len(src) && isJSIdentPart(src[i]) {
+					i++
+				}
+				return i
+			}
+		}
+	}
+	return i
+}
+
+type jsVM struct {
+	toks  []jsTok
+	match []int // 括号对应位置
+	pos   int
+	steps int
+	depth int
+	alloc int
+}
+
+func newJSVM(src string) *jsVM {
+	vm := &jsVM{toks: jsLex(src)}
+	vm.match = make([]int, len(vm.toks))
+	var stack []int
+	for i, t := range vm.toks {
+		vm.match[i] = -1
+		if t.kind != jsTokPunct {
+			continue
+		}
+		switch t.val {
+		case "(", "[", "{":
+			stack = append(stack, i)
+		case ")", "]", "}":
+			if len(stack) > 0 {
+				open := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				vm.match[open], vm.match[i] = i, open
+			}
+		}
+	}
+	return vm
+}
+
+func (vm *jsVM) peek(offset int) jsTok {
+	if i := vm.pos + offset; i >= 0 && i < len(vm.toks) {
+		return vm.toks[i]
+	}
+	return jsTok{kind: jsTokPunct, val: ""}
+}
+
+func (vm *jsVM) expect(val string) error {
+	if !vm.peek(0).is(val) {
+		return fmt.Errorf("js: expect %q at token %d", val, vm.pos)
+	}
+	vm.pos++
+	return nil
+}
+
+func (vm *jsVM) step() error {
+	vm.steps++
+	if vm.steps > jsMaxSteps {
+		return errJSBudget
+	}
+	return nil
+}
+
+// 转为字符串并计入预算,避免每一步都复制大字符串
+func (vm *jsVM) str(v any) (string, error) {
+	s := jsToString(v)
+	vm.alloc += len(s)
+	if vm.alloc > jsMaxAlloc {
+		return "", errJSBudget
+	}
+	return s, nil
+}
+
+func (vm *jsVM) add(a, b any) (any, error) {
+	_, as := a.(string)
+	_, bs := b.(string)
+	if !as && !bs {
+		return jsToNumber(a) + jsToNumber(b), nil
+	}
+	x, err := vm.str(a)
+	if err != nil {
+		return nil, err
+	}
+	y, err := vm.str(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(x)+len(y) > jsMaxString {
+		return nil, nil
+	}
+	return x + y, nil
+}
+
+// 判断 { 是否为函数体
+func (vm *jsVM) isFunctionBody(i int) bool {
+	if i == 0 {
+		return false
+	}
+	prev := vm.toks[i-1]
+	if prev.is("=>") {
+		return true
+	}
+	if !prev.is(")") || vm.match[i-1] < 1 {
+		return false
+	}
+	j := vm.match[i-1] - 1
+	if vm.toks[j].is("function") {
+		return true
+	}
+	return vm.toks[j].kind == jsTokIdent && j > 0 && vm.toks[j-1].is("function")
+}
+
+// 顺序执行 [start,end) 范围内的语句,嵌套函数只声明不执行
+// 函数声明由调用方先提升,call 为 true 时遇到 return 返回
+func (vm *jsVM) execRange(env *jsEnv, start, end int, call bool) (any, bool, error) {
+	for vm.pos = start; vm.pos < end; {
+		if err := vm.step(); err != nil {
+			return nil, false, err
+		}
+		t := vm.toks[vm.pos]
+		begin := vm.pos
+		var err error
+		switch {
+		case t.is("function"):
+			_, err = vm.parseFunction(env)
+		case t.is("var") || t.is("let") || t.is("const"):
+			vm.pos++
+			err = vm.execDeclare(env)
+		case t.is("return") && call:
+			vm.pos++
+			if vm.peek(0).is(";") || vm.peek(0).is("}") {
+				return nil, true, nil
+			}
+			v, err := vm.parseExpr(env)
+			if errors.Is(err, errJSBudget) {
+				return nil, false, err
+			}
+			return v, true, nil
+		case t.is("=>") && vm.peek(1).is("{"):
+			vm.pos = vm.match[vm.pos+1] + 1
+		case t.kind == jsTokIdent && !vm.peek(-1).is(".") && isJSAssignOp(vm.peek(1)):
+			_, err = vm.parseExpr(env)
+		default:
+			vm.pos++
+		}
+		if errors.Is(err, errJSBudget) {
+			return nil, false, err
+		}
+		if err != nil || vm.pos <= begin {
+			vm.pos = begin + 1
+		}
+	}
+	return nil, false, nil
+}
+
+// 函数声明提升
+func (vm *jsVM) hoist(env *jsEnv, start, end int) {
+	for i := start; i < end; i++ {
+		t := vm.toks[i]
+		if t.is("{") && vm.isFunctionBody(i) && vm.match[i] > i {
+			i = vm.match[i]
+			continue
+		}
+		if t.is("function") && i+1 < end && vm.toks[i+1].kind == jsTokIdent {
+			pos := vm.pos
+			vm.pos = i
+			if _, err := vm.parseFunction(env); err == nil {
+				i = vm.pos - 1
+			}
+			vm.pos = pos
+		}
+	}
+}
+
+func isJSAssignOp(t jsTok) bool {
+	return t.kind == jsTokPunct && (t.val == "=" || t.val == "+=" || t.val == "-=" || t.val == "*=" || t.val == "/=")
+}
+
+func (vm *jsVM) execDeclare(env *jsEnv) error {
+	for {
+		name := vm.peek(0)
+		if name.kind != jsTokIdent {
+			return fmt.Errorf("js: unsupported declaration at token %d", vm.pos)
+		}
+		vm.pos++
+		if vm.peek(0).is("=") {
+			vm.pos++
+			v, err := vm.parseAssign(env)
+			if err != nil {
+				return err
+			}
+			env.vars[name.val] = v
+		} else if _, ok := env.vars[name.val]; !ok {
+			env.vars[name.val] = nil
+		}
+		if !vm.peek(0).is(",") {
+			return nil
+		}
+		vm.pos++
+	}
+}
+
+func (vm *jsVM) parseExpr(env *jsEnv) (any, error) {
+	return vm.parseAssign(env)
+}
+
+func (vm *jsVM) parseAssign(env *jsEnv) (any, error) {
+	if t := vm.peek(0); t.kind == jsTokIdent && !isJSKeyword(t.val) && isJSAssignOp(vm.peek(1)) {
+		op := vm.peek(1).val
+		vm.pos += 2
+		v, err := vm.parseAssign(env)
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "+=":
+			if v, err = vm.add(env.get(t.val), v); err != nil {
+				return nil, err
+			}
+		case "-=":
+			v = jsToNumber(env.get(t.val)) - jsToNumber(v)
+		case "*=":
+			v = jsToNumber(env.get(t.val)) * jsToNumber(v)
+		case "/=":
+			v = jsToNumber(env.get(t.val)) / jsToNumber(v)
+		}
+		env.assign(t.val, v)
+		return v, nil
+	}
+	return vm.parseTernary(env)
+}
+
+func (vm *jsVM) parseTernary(env *jsEnv) (any, error) {
+	cond, err := vm.parseBinary(env, 0)
+	if err != nil || !vm.peek(0).is("?") {
+		return cond, err
+	}
+	vm.pos++
+	a, err := vm.parseAssign(env)
+	if err != nil {
+		return nil, err
+	}
+	if err := vm.expect(":"); err != nil {
+		return nil, err
+	}
+	b, err := vm.parseAssign(env)
+	if err != nil {
+		return nil, err
+	}
+	if jsTruthy(cond) {
+		return a, nil
+	}
+	return b, nil
+}
+
+// 二元运算符优先级,从低到高
+var jsBinaryOps = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "===", "!=="},
+	{"<", ">", "<=", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (vm *jsVM) parseBinary(env *jsEnv, level int) (any, error) {
+	if level == len(jsBinaryOps) {
+		return vm.parseUnary(env)
+	}
+	left, err := vm.parseBinary(env, level+1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := vm.peek(0)
+		if t.kind != jsTokPunct || !containsString(jsBinaryOps[level], t.val) {
+			return left, nil
+		}
+		vm.pos++
+		right, err := vm.parseBinary(env, level+1)
+		if err != nil {
+			return nil, err
+		}
+		if t.val == "+" {
+			if left, err = vm.add(left, right); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		left = jsBinary(t.val, left, right)
+	}
+}
+
+func containsString(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (vm *jsVM) parseUnary(env *jsEnv) (any, error) {
+	t := vm.peek(0)
+	switch {
+	case t.is("!"), t.is("-"), t.is("+"), t.is("typeof"), t.is("void"):
+		vm.pos++
+		v, err := vm.parseUnary(env)
+		if err != nil {
+			return nil, err
+		}
+		switch t.val {
+		case "!":
+			return !jsTruthy(v), nil
+		case "-":
+			return -jsToNumber(v), nil
+		case "+":
+			return jsToNumber(v), nil
+		case "typeof":
+			return jsTypeof(v), nil
+		}
+		return nil, nil
+	}
+	return vm.parsePostfix(env)
+}
+
+func (vm *jsVM) parsePostfix(env *jsEnv) (any, error) {
+	v, err := vm.parsePrimary(env)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch t := vm.peek(0); {
+		case t.is("."):
+			vm.pos++
+			name := vm.peek(0)
+			if name.kind != jsTokIdent {
+				return nil, fmt.Errorf("js: expect property name at token %d", vm.pos)
+			}
+			vm.pos++
+			v = jsMember(v, name.val)
+		case t.is("["):
+			vm.pos++
+			key, err := vm.parseExpr(env)
+			if err != nil {
+				return nil, err
+			}
+			if err := vm.expect("]"); err != nil {
+				return nil, err
+			}
+			name, err := vm.str(key)
+			if err != nil {
+				return nil, err
+			}
+			v = jsMember(v, name)
+		case t.is("("):
+			args, err := vm.parseArgs(env)
+			if err != nil {
+				return nil, err
+			}
+			if v, err = vm.call(v, args); err != nil {
+				return nil, err
+			}
+		case t.is("++"), t.is("--"):
+			vm.pos++
+		default:
+			return v, nil
+		}
+	}
+}
+
+func (vm *jsVM) parseArgs(env *jsEnv) ([]any, error) {
+	if err := vm.expect("("); err != nil {
+		return nil, err
+	}
+	var args []any
+	for !vm.peek(0).is(")") {
+		v, err := vm.parseAssign(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+		if !vm.peek(0).is(",") {
+			break
+		}
+		vm.pos++
+	}
+	return args, vm.expect(")")
+}
+
+func (vm *jsVM) parsePrimary(env *jsEnv) (any, error) {
+	if err := vm.step(); err != nil {
+		return nil, err
+	}
+	t := vm.peek(0)
+	switch t.kind {
+	case jsTokNum:
+		vm.pos++
+		return t.num, nil
+	case jsTokStr:
+		vm.pos++
+		return t.val, nil
+	case jsTokRegex:
+		vm.pos++
+		return nil, nil
+	case jsTokIdent:
+		switch t.val {
+		case "true", "false":
+			vm.pos++
+			return t.val == "true", nil
+		case "null":
+			vm.pos++
+			return jsNull{}, nil
+		case "undefined":
+			vm.pos++
+			return nil, nil
+		case "function":
+			return vm.parseFunction(env)
+		case "new":
+			vm.pos++
+			return vm.parsePostfix(env)
+		}
+		if isJSKeyword(t.val) {
+			return nil, fmt.Errorf("js: unsupported keyword %s", t.val)
+		}
+		if vm.peek(1).is("=>") {
+			return vm.parseArrow(env, []string{t.val}, vm.pos+2)
+		}
+		vm.pos++
+		return env.get(t.val), nil
+	}
+
+	switch t.val {
+	case "(":
+		close := vm.match[vm.pos]
+		if close > 0 && close+1 < len(vm.toks) && vm.toks[close+1].is("=>") {
+			var params []string
+			for i := vm.pos + 1; i < close; i++ {
+				if vm.toks[i].kind == jsTokIdent {
+					params = append(params, vm.toks[i].val)
+				}
+			}
+			return vm.parseArrow(env, params, close+2)
+		}
+		vm.pos++
+		v, err := vm.parseExpr(env)
+		if err != nil {
+			return nil, err
+		}
+		return v, vm.expect(")")
+	case "{":
+		return vm.parseObject(env)
+	case "[":
+		vm.pos++
+		var arr []any
+		for !vm.peek(0).is("]") {
+			v, err := vm.parseAssign(env)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+			if !vm.peek(0).is(",") {
+				break
+			}
+			vm.pos++
+		}
+		return arr, vm.expect("]")
+	}
+	return nil, fmt.Errorf("js: unexpected token %q at %d", t.val, vm.pos)
+}
+
+// 解析函数,具名函数同时声明到当前作用域
+func (vm *jsVM) parseFunction(env *jsEnv) (any, error) {
+	if err := vm.expect("function"); err != nil {
+		return nil, err
+	}
+	var name string
+	if t := vm.peek(0); t.kind == jsTokIdent {
+		name = t.val
+		vm.pos++
+	}
+	if !vm.peek(0).is("(") || vm.match[vm.pos] < 0 {
+		return nil, fmt.Errorf("js: expect function params at token %d", vm.pos)
+	}
+	fn := &jsFunc{closure: env}
+	close := vm.match[vm.pos]
+	for i := vm.pos + 1; i < close; i++ {
+		if vm.toks[i].kind == jsTokIdent {
+			fn.params = append(fn.params, vm.toks[i].val)
+		}
+	}
+	vm.pos = close + 1
+	if !vm.peek(0).is("{") || vm.match[vm.pos] < 0 {
+		return nil, fmt.Errorf("js: expect function body at token %d", vm.pos)
+	}
+	fn.start, fn.end = vm.pos+1, vm.match[vm.pos]
+	vm.pos = fn.end + 1
+	if name != "" {
+		env.vars[name] = fn
+	}
+	return fn, nil
+}
+
+// 解析箭头函数,start 为函数体开始位置
+func (vm *jsVM) parseArrow(env *jsEnv, params []string, start int) (any, error) {
+	fn := &jsFunc{params: params, closure: env}
+	vm.pos = start
+	if vm.peek(0).is("{") && vm.match[vm.pos] > 0 {
+		fn.start, fn.end = vm.pos+1, vm.match[vm.pos]
+		vm.pos = fn.end + 1
+		return fn, nil
+	}
+	// 表达式体,使用空参数求值一次以跳过
+	fn.expr = true
+	fn.start = vm.pos
+	if _, err := vm.parseAssign(newJSEnv(env)); err != nil {
+		return nil, err
+	}
+	fn.end = vm.pos
+	return fn, nil
+}
+
+func (vm *jsVM) parseObject(env *jsEnv) (map[string]any, error) {
+	if err := vm.expect("{"); err != nil {
+		return nil, err
+	}
+	obj := make(map[string]any)
+	for !vm.peek(0).is("}") {
+		key := vm.peek(0)
+		if key.kind != jsTokIdent && key.kind != jsTokStr && key.kind != jsTokNum {
+			return nil, fmt.Errorf("js: unexpected object key %q at token %d", key.val, vm.pos)
+		}
+		vm.pos++
+		if vm.peek(0).is(",") || vm.peek(0).is("}") {
+			obj[key.val] = env.get(key.val)
+		} else {
+			if err := vm.expect(":"); err != nil {
+				return nil, err
+			}
+			v, err := vm.parseAssign(env)
+			if err != nil {
+				return nil, err
+			}
+			obj[key.val] = v
+		}
+		if !vm.peek(0).is(",") {
+			break
+		}
+		vm.pos++
+	}
+	return obj, vm.expect("}")
+}
+
+func (vm *jsVM) call(callee any, args []any) (any, error) {
+	fn, ok := callee.(*jsFunc)
+	if !ok {
+		return nil, nil
+	}
+	if fn.native != nil {
+		// 内置函数都会把参数转为字符串,先计入预算
+		for i, a := range args {
+			switch a.(type) {
+			case string, []any:
+				s, err := vm.str(a)
+				if err != nil {
+					return nil, err
+				}
+				args[i] = s
+			}
+		}
+		return fn.native(args), nil
+	}
+	if vm.depth >= jsMaxDepth {
+		return nil, errJSBudget
+	}
+	vm.depth++
+	defer func() { vm.depth-- }()
+
+	env := newJSEnv(fn.closure)
+	for i, name := range fn.params {
+		var v any
+		if i < len(args) {
+			v = args[i]
+		}
+		env.vars[name] = v
+	}
+
+	pos := vm.pos
+	defer func() { vm.pos = pos }()
+	if fn.expr {
+		vm.pos = fn.start
+		return vm.parseAssign(env)
+	}
+	vm.hoist(env, fn.start, fn.end)
+	v, _, err := vm.execRange(env, fn.start, fn.end, true)
+	return v, err
+}
+
+func isJSKeyword(name string) bool {
+	switch name {
+	case "var", "let", "const", "function", "return", "if", "else", "for", "while", "do", "switch", "case",
+		"break", "continue", "new", "delete", "typeof", "void", "in", "instanceof", "this", "try", "catch", "finally", "throw":
+		return true
+	}
+	return false
+}
+
+func jsBinary(op string, a, b any) any {
+	switch op {
+	case "||":
+		if jsTruthy(a) {
+			return a
+		}
+		return b
+	case "&&":
+		if !jsTruthy(a) {
+			return a
+		}
+		return b
+	case "==", "===":
+		return jsEquals(a, b, op == "===")
+	case "!=", "!==":
+		return !jsEquals(a, b, op == "!==")
+	case "<", ">", "<=", ">=":
+		var c int
+		as, aok := a.(string)
+		bs, bok := b.(string)
+		if aok && bok {
+			c = strings.Compare(as, bs)
+		} else {
+			x, y := jsToNumber(a), jsToNumber(b)
+			if math.IsNaN(x) || math.IsNaN(y) {
+				return false
+			}
+			switch {
+			case x < y:
+				c = -1
+			case x > y:
+				c = 1
+			}
+		}
+		switch op {
+		case "<":
+			return c < 0
+		case ">":
+			return c > 0
+		case "<=":
+			return c <= 0
+		}
+		return c >= 0
+	case "-":
+		return jsToNumber(a) - jsToNumber(b)
+	case "*":
+		return jsToNumber(a) * jsToNumber(b)
+	case "/":
+		return jsToNumber(a) / jsToNumber(b)
+	case "%":
+		return math.Mod(jsToNumber(a), jsToNumber(b))
+	}
+	return nil
+}
+
+func jsEquals(a, b any, strict bool) bool {
+	if strict || jsTypeof(a) == jsTypeof(b) {
+		switch a := a.(type) {
+		case string, float64, bool, jsNull, nil:
+			return a == b
+		}
+		return false
+	}
+	_, an := a.(jsNull)
+	_, bn := b.(jsNull)
+	if (a == nil || an) && (b == nil || bn) {
+		return true
+	}
+	return jsToNumber(a) == jsToNumber(b)
+}
+
+func jsTruthy(v any) bool {
+	switch v := v.(type) {
+	case nil, jsNull:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	}
+	return true
+}
+
+func jsTypeof(v any) string {
+	switch v.(type) {
+	case nil:
+		return "undefined"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case *jsFunc:
+		return "function"
+	}
+	return "object"
+}
+
+func jsToNumber(v any) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case jsNull:
+		return 0
+	case string:
+		s := strings.TrimSpace(v)
+		if s == "" {
+			return 0
+		}
+		return jsParseNumber(s)
+	}
+	return math.NaN()
+}
+
+func jsToString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "undefined"
+	case jsNull:
+		return "null"
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+			return strconv.FormatInt(int64(v), 10)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		var b strings.Builder
+		jsJoin(v, &b)
+		return b.String()
+	case *jsFunc:
+		return "function"
+	}
+	return "[object Object]"
+}
+
+// 数组转为字符串,超过 jsMaxString 后停止拼接
+// 嵌套数组可能共享元素,逐层 Join 的结果会指数增长
+func jsJoin(v []any, b *strings.Builder) {
+	for i, e := range v {
+		if b.Len() > jsMaxString {
+			return
+		}
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if arr, ok := e.([]any); ok {
+			jsJoin(arr, b)
+		} else {
+			b.WriteString(jsToString(e))
+		}
+	}
+}
+
+func jsMember(v any, name string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return v[name]
+	case string:
+		if name == "length" {
+			return float64(len([]rune(v)))
+		}
+	case []any:
+		if name == "length" {
+			return float64(len(v))
+		}
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(v) {
+			return v[i]
+		}
+	}
+	return nil
+}
+
+func newJSGlobal() *jsEnv {
+	env := newJSEnv(nil)
+	native := func(f func(args []any) any) *jsFunc { return &jsFunc{native: f} }
+	arg := func(args []any, i int) any {
+		if i < len(args) {
+			return args[i]
+		}
+		return nil
+	}
+	env.vars["parseInt"] = native(func(args []any) any {
+		s := strings.TrimSpace(jsToString(arg(args, 0)))
+		end := 0
+		for end < len(s) && (isJSDigit(s[end]) || (end == 0 && (s[0] == '-' || s[0] == '+'))) {
+			end++
+		}
+		n, err := strconv.ParseInt(s[:end], 10, 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(n)
+	})
+	env.vars["String"] = native(func(args []any) any { return jsToString(arg(args, 0)) })
+	env.vars["Number"] = native(func(args []any) any { return jsToNumber(arg(args, 0)) })
+	env.vars["encodeURIComponent"] = native(func(args []any) any {
+		return strings.ReplaceAll(url.QueryEscape(jsToString(arg(args, 0))), "+", "%20")
+	})
+	return env
+}
+
+// 保留 script 中的内容,其余替换为空格,保证位置不变
+func jsScriptOnly(html string) string {
+	lower := asciiLower(html)
+	if !strings.Contains(lower, "<script") {
+		return html
+	}
+	buf := []byte(strings.Repeat(" ", len(html)))
+	for i := 0; ; {
+		start := strings.Index(lower[i:], "<script")
+		if start < 0 {
+			break
+		}
+		start += i
+		open := strings.IndexByte(lower[start:], '>')
+		if open < 0 {
+			break
+		}
+		open += start + 1
+		end := strings.Index(lower[open:], "</script")
+		if end < 0 {
+			end = len(html) - open
+		}
+		copy(buf[open:], html[open:open+end])
+		i = open + end
+	}
+	return string(buf)
+}
+
+// 只转换 ASCII 字母,strings.ToLower 会改变无效 UTF-8 等内容的长度,偏移无法对应
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// 求值 html 中 offset 位置的对象字面量
+// 先执行全局语句,再依次执行外层函数体中位于对象之前的语句
+func EvalJSObjectAt(html string, offset int) (map[string]any, error) {
+	vm := newJSVM(jsScriptOnly(html))
+
+	target := -1
+	for i, t := range vm.toks {
+		if t.pos == offset {
+			target = i
+			break
+		}
+	}
+	if target < 0 || !vm.toks[target].is("{") {
+		return nil, fmt.Errorf("js: object not found at %d", offset)
+	}
+
+	var bodies []int
+	for i := 0; i < target; i++ {
+		if vm.toks[i].is("{") && vm.match[i] > target && vm.isFunctionBody(i) {
+			bodies = append(bodies, i)
+		}
+	}
+
+	// 函数声明在整个作用域内提升,对象之后声明的函数也可以调用
+	env := newJSGlobal()
+	vm.hoist(env, 0, len(vm.toks))
+	end := len(vm.toks)
+	if len(bodies) == 0 {
+		end = target
+	}
+	if _, _, err := vm.execRange(env, 0, end, false); err != nil {
+		return nil, err
+	}
+	for i, body := range bodies {
+		env = newJSEnv(env)
+		vm.hoist(env, body+1, vm.match[body])
+		end := vm.match[body]
+		if i == len(bodies)-1 {
+			end = target
+		}
+		if _, _, err := vm.execRange(env, body+1, end, false); err != nil {
+			return nil, err
+		}
+	}
+
+	vm.pos = target
+	return vm.parseObject(env)
+}
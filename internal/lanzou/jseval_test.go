@@ -0,0 +1,157 @@
+package lanzou
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func evalData(t *testing.T, html string) map[string]string {
+	t.Helper()
+	param, err := htmlJsonToMap(html)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return param
+}
+
+func assertParam(t *testing.T, param map[string]string, want map[string]string) {
+	t.Helper()
+	for k, v := range want {
+		if param[k] != v {
+			t.Errorf("%s = %q, want %q (all: %v)", k, param[k], v, param)
+		}
+	}
+}
+
+func TestEvalVars(t *testing.T) {
+	param := evalData(t, `<script>
+	var a = 'ab', b = "cd";
+	var n = 3;
+	var s = a + b + n;
+	var t = n > 2 ? 'big' : 'small';
+	n += 1;
+	data : { 's':s, 't':t, 'n':n, 'k':1, 'e':'' },
+</script>`)
+	assertParam(t, param, map[string]string{"s": "abcd3", "t": "big", "n": "4", "k": "1", "e": ""})
+}
+
+func TestEvalFunctionScope(t *testing.T) {
+	param := evalData(t, `<script>
+	var sign = 'outer';
+	function down(){
+		var sign = 'inner' + suffix();
+		$.ajax({
+			url : '/ajaxm.php',
+			data : { 'sign':sign, 'v':ver },
+		});
+	}
+	var ver = 2;
+	function suffix(){ return '!' }
+</script>`)
+	assertParam(t, param, map[string]string{"sign": "inner!", "v": "2"})
+}
+
+// 对象之后声明的函数也能调用
+func TestEvalHoist(t *testing.T) {
+	t.Run("top level", func(t *testing.T) {
+		param := evalData(t, `<script>data : {'q': g(2)}; function g(x){return x*2}</script>`)
+		assertParam(t, param, map[string]string{"q": "4"})
+	})
+	t.Run("function body", func(t *testing.T) {
+		param := evalData(t, `<script>function more(){ $.ajax({ data : {'q': pre() + g(3)} }); function g(x){ return x + 1 } }
+function pre(){ return 'p' }</script>`)
+		assertParam(t, param, map[string]string{"q": "p4"})
+	})
+}
+
+// 注释中的 data 不参与解析
+func TestEvalComment(t *testing.T) {
+	html := RemoveNotes(`<script>
+	var wp_sign = 'new';
+	//data : { 'sign':'old' },
+	data : { 'sign':wp_sign },
+</script>`)
+	assertParam(t, evalData(t, html), map[string]string{"sign": "new"})
+}
+
+// 无法求值的字段使用正则兜底
+func TestEvalFallback(t *testing.T) {
+	param := evalData(t, `<script>
+	var pwd = document.getElementById('pwd').value;
+	var k = 'key';
+	data : { 'pwd':pwd, 'k':k, 'lx':2 },
+</script>`)
+	assertParam(t, param, map[string]string{"k": "key", "lx": "2"})
+}
+
+func TestEvalBudget(t *testing.T) {
+	// 死循环调用不能卡住解析
+	html := `<script>function f(){ return f() } data : { 'a':f(), 'b':'ok' }</script>`
+	start := strings.Index(html, "{ 'a'")
+	if _, err := EvalJSObjectAt(html, start); err == nil {
+		t.Fatal("recursive call evaluated without error")
+	}
+	assertParam(t, evalData(t, html), map[string]string{"b": "ok"})
+}
+
+func TestEvalStringLimit(t *testing.T) {
+	// 反复翻倍的字符串和嵌套数组不能耗尽内存
+	html := `<script>var s = 'aaaaaaaa'; var a = [s];` +
+		strings.Repeat("s = s + s; a = [a, a];", 28) +
+		`data : { 's':s, 'a':a + '', 'b':'ok' }</script>`
+	param := evalData(t, html)
+	assertParam(t, param, map[string]string{"b": "ok"})
+	if len(param["s"]) > jsMaxString || len(param["a"]) > jsMaxString+len("aaaaaaaa,") {
+		t.Fatalf("string length %d, array string length %d", len(param["s"]), len(param["a"]))
+	}
+}
+
+func TestJSScriptOnly(t *testing.T) {
+	for _, html := range []string{
+		"<sCript\x80>",
+		"\xc4\xb0<SCRIPT>var a = 1</SCRIPT>\u0130",
+		"<p>x</p><script type='text/javascript'>var a = 1;</script><script>",
+	} {
+		if got := jsScriptOnly(html); len(got) != len(html) {
+			t.Errorf("jsScriptOnly(%q) changed length %d -> %d", html, len(html), len(got))
+		}
+	}
+}
+
+// 分享页面可能被篡改或损坏,任意输入都不能 panic
+func FuzzEvalJSObjectAt(f *testing.F) {
+	for _, name := range []string{"share/file/02-get.html", "share/file_pwd/01-get.html", "share/folder/01-get.html"} {
+		page, err := os.ReadFile("testdata/" + name)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(page), strings.Index(string(page), "data :")+7)
+	}
+	f.Add("<sCript\x80>", 0)
+	f.Add(`<script>var s='a'; s = s + s; data : { 'a':s }</script>`, 41)
+	f.Fuzz(func(t *testing.T, html string, offset int) {
+		if got := jsScriptOnly(html); len(got) != len(html) {
+			t.Fatalf("jsScriptOnly changed length %d -> %d", len(html), len(got))
+		}
+		EvalJSObjectAt(html, offset)
+		htmlJsonToMap(html)
+	})
+}
+
+func TestFindJSVarFunc(t *testing.T) {
+	// sasign 出现三次时第二个为有效值
+	data := `var sasign = 'a'; var sasign = 'b'; var sasign = 'c';`
+	if v := findJSVarFunc("sasign", data); v != "b" {
+		t.Fatalf("sasign = %q, want b", v)
+	}
+	if v := findJSVarFunc("sasign", `var sasign = 'a'; var sasign = 'b';`); v != "a" {
+		t.Fatalf("sasign = %q, want a", v)
+	}
+	if v := findJSVarFunc("sign", data+`var sign = "x";`); v != "x" {
+		t.Fatalf("sign = %q, want x", v)
+	}
+	if v := findJSVarFunc("missing", data); v != "" {
+		t.Fatalf("missing = %q", v)
+	}
+}
@@ -0,0 +1,29 @@
+package lanzou
+
+/*
+日志
+本包不依赖 SDK,插件初始化时通过 SetLogger 接入 OpenList 的日志
+*/
+
+type Logger interface {
+	Debugf(format string, v ...any)
+	Infof(format string, v ...any)
+	Warnf(format string, v ...any)
+	Errorf(format string, v ...any)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(string, ...any) {}
+func (nopLogger) Infof(string, ...any)  {}
+func (nopLogger) Warnf(string, ...any)  {}
+func (nopLogger) Errorf(string, ...any) {}
+
+var log Logger = nopLogger{}
+
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	log = l
+}
@@ -0,0 +1,135 @@
+package lanzou
+
+import (
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"openlist-lanzou-plugin/internal/fixture"
+
+	"resty.dev/v3"
+)
+
+/*
+回放 testdata/share 下的分享页面
+新用例使用 tools/lzrecord 录制,现有用例是手工编写的,标记为 synthetic,见 testdata/README.md
+*/
+
+// 回放使用的客户端,不重试,避免多消耗录制的响应
+func replayClient(url string) *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{
+		BaseUrl:           url,
+		ShareUrl:          url,
+		CookieJar:         jar,
+		Client:            resty.New().SetCookieJar(jar),
+		ClientNotRedirect: resty.New().SetCookieJar(jar).SetRedirectPolicy(resty.NoRedirectPolicy()),
+		UploadClient:      resty.New().SetCookieJar(jar),
+		FolderPasswords:   map[string]string{},
+	}
+}
+
+func toShareFiles(files []FileOrFolderByShareUrl) []fixture.ShareFile {
+	list := make([]fixture.ShareFile, 0, len(files))
+	for _, file := range files {
+		list = append(list, fixture.ShareFile{
+			ID:    file.ID,
+			Name:  file.GetName(),
+			Size:  file.Size,
+			Time:  file.Time,
+			Url:   file.Url,
+			Pwd:   file.Pwd,
+			IsDir: file.IsFloder,
+		})
+	}
+	return list
+}
+
+// 回放用例,返回解析结果
+func replay(t *testing.T, f *fixture.Fixture) []fixture.ShareFile {
+	t.Helper()
+	replayer := &fixture.Replayer{Fixture: f, Errorf: t.Errorf}
+	srv := httptest.NewServer(replayer)
+	defer srv.Close()
+	replayer.Host = srv.URL
+
+	files, err := replayClient(srv.URL).GetFileOrFolderByShareUrl(f.ShareID, f.Pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := replayer.Remaining(); n != 0 {
+		t.Errorf("%d recorded exchanges not replayed", n)
+	}
+
+	got := toShareFiles(files)
+	for i := range got {
+		got[i].Url = strings.ReplaceAll(got[i].Url, srv.URL, fixture.HostMark)
+	}
+	return got
+}
+
+func TestShareReplay(t *testing.T) {
+	dirs, err := fixture.List("testdata/share")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dirs) == 0 {
+		t.Fatal("no fixtures in testdata/share")
+	}
+	for _, dir := range dirs {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			f, err := fixture.Load(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Synthetic {
+				t.Log("synthetic fixture, not recorded from LanZou")
+			}
+			if got := replay(t, f); !reflect.DeepEqual(got, f.Want) {
+				t.Fatalf("got  %+v\nwant %+v", got, f.Want)
+			}
+		})
+	}
+}
+
+// 录制本地模拟的蓝奏云再回放,结果应与直接请求一致
+func TestRecordReplay(t *testing.T) {
+	fake := newFakeLanZou(t)
+	dir := fake.addFolder("-1", "docs", "1234")
+	fake.addFolder(dir, "sub", "")
+	for _, name := range []string{"a.zip", "b.zip", "c.zip"} {
+		fake.addFile(dir, name, "", "data")
+	}
+	file := fake.addFile("-1", "d.zip", "abcd", "hello")
+
+	for _, share := range []struct{ id, pwd string }{{"b" + dir, "1234"}, {"i" + file, "abcd"}} {
+		recorder := &fixture.Recorder{}
+		c := fake.client()
+		c.Client.SetTransport(recorder)
+		c.ClientNotRedirect.SetTransport(recorder)
+		files, err := c.GetFileOrFolderByShareUrl(share.id, share.pwd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f := &fixture.Fixture{ShareID: share.id, Pwd: share.pwd, Exchanges: recorder.Exchanges()}
+		for _, file := range toShareFiles(files) {
+			file.Url = recorder.Clean(file.Url)
+			f.Want = append(f.Want, file)
+		}
+		out := filepath.Join(t.TempDir(), share.id)
+		if err := f.Save(out); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := fixture.Load(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := replay(t, loaded); !reflect.DeepEqual(got, f.Want) {
+			t.Fatalf("%s: got  %+v\nwant %+v", share.id, got, f.Want)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+package lanzou
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"resty.dev/v3"
+)
+
+/*
+通过分享链接获取数据
+*/
+
+// 判断类容
+var isFileReg = regexp.MustCompile(`class="fileinfo"|id="file"|文件描述`)
+var isFolderReg = regexp.MustCompile(`id="infos"`)
+
+// 获取文件文件夹基础信息
+
+// 获取文件名称
+var nameFindReg = regexp.MustCompile(`<title>(.+?) - 蓝奏云</title>|id="filenajax">(.+?)</div>|var filename = '(.+?)';|<div style="font-size.+?>([^<>].+?)</div>|<div class="filethetext".+?>([^<>]+?)</div>`)
+
+// 获取文件大小
+var sizeFindReg = regexp.MustCompile(`(?i)大小\W*([0-9.]+\s*[bkm]+)`)
+
+// 获取文件时间
+var timeFindReg = regexp.MustCompile(`\d+\s*[秒天分小][钟时]?前|[昨前]天|\d{4}-\d{2}-\d{2}`)
+
+// 查找分享文件夹子文件夹ID和名称
+var findSubFolderReg = regexp.MustCompile(`(?i)(?:folderlink|mbxfolder).+href="/(.+?)"(?:.+filename")?>(.+?)<`)
+
+// 获取下载页面链接
+var findDownPageParamReg = regexp.MustCompile(`<iframe.*?src="(.+?)"`)
+
+// 获取文件ID
+var findFileIDReg = regexp.MustCompile(`'/ajaxm\.php\?file=(\d+)'`)
+
+// 判断分享页面是否需要提取码
+func isPasswordPage(html string) bool {
+	return strings.Contains(html, "pwdload") || strings.Contains(html, "passwddiv")
+}
+
+// 判断分享接口的提示是否为提取码错误,部分情况 zt 为成功,只比较提示
+func isWrongPasswordInfo(info string) bool {
+	info = strings.TrimSpace(info)
+	for _, ie := range infoErrors {
+		if ie.err == ErrWrongPassword && ie.info == info {
+			return true
+		}
+	}
+	return false
+}
+
+// 提取码错误时区分未填写和填写错误
+func sharePasswordError(shareID, pwd string, err error) error {
+	if !errors.Is(err, ErrWrongPassword) {
+		return err
+	}
+	if pwd == "" {
+		return fmt.Errorf("%w: %s", ErrSharePasswordRequired, shareID)
+	}
+	return fmt.Errorf("%s: %w", shareID, err)
+}
+
+// 获取分享链接主界面
+func (c *Client) getShareUrlHtml(shareID string) (string, error) {
+	firstPageData, err := c.Get(MustUrlJoin(c.ShareUrl, shareID), nil)
+	if err != nil {
+		return "", err
+	}
+
+	firstPageDataStr := RemoveNotes(string(firstPageData))
+	if strings.Contains(firstPageDataStr, "取消分享") {
+		return "", ErrFileShareCancel
+	}
+	if strings.Contains(firstPageDataStr, "文件不存在") {
+		return "", ErrFileNotExist
+	}
+
+	return firstPageDataStr, nil
+}
+
+// 通过分享链接获取文件或文件夹
+func (c *Client) GetFileOrFolderByShareUrl(shareID, pwd string) ([]FileOrFolderByShareUrl, error) {
+	pageData, err := c.getShareUrlHtml(shareID)
+	if err != nil {
+		return nil, err
+	}
+	page := ParseSharePage(pageData)
+	if page.Kind != SharePageKindFile {
+		return c.getFolderByShareUrl(shareID, pwd, pageData, page)
+	} else {
+		file, err := c.getFilesByShareUrl(shareID, pwd, pageData, page)
+		if err != nil {
+			return nil, err
+		}
+		return []FileOrFolderByShareUrl{*file}, nil
+	}
+}
+
+// 通过分享链接获取文件(下载链接也使用此方法)
+// FileOrFolderByShareUrl 包含 pwd 和 url 字段
+// 参考 https://github.com/zaxtyson/LanZouCloud-API/blob/ab2e9ec715d1919bf432210fc16b91c6775fbb99/lanzou/api/core.py#L440
+func (c *Client) GetFilesByShareUrl(shareID, pwd string) (file *FileOrFolderByShareUrl, err error) {
+	pageData, err := c.getShareUrlHtml(shareID)
+	if err != nil {
+		return nil, err
+	}
+	return c.getFilesByShareUrl(shareID, pwd, pageData, ParseSharePage(pageData))
+}
+
+// page 为 sharePageData 的解析结果
+func (c *Client) getFolderByShareUrl(shareID, pwd string, sharePageData string, page *SharePage) ([]FileOrFolderByShareUrl, error) {
+	from, err := htmlJsonToMap(sharePageData)
+	if err != nil {
+		return nil, err
+	}
+	if len(from) == 0 {
+		return nil, fmt.Errorf("%w: htmlJsonToMap not find data", ErrTemplateChanged)
+	}
+
+	// 加密的文件夹先尝试上级提取码,提取码错误时再尝试配置的提取码
+	files, err := c.getShareFolderFiles(from, pwd)
+	if errors.Is(err, ErrWrongPassword) {
+		if p := c.FolderPasswords[shareID]; p != "" && p != pwd {
+			pwd = p
+			files, err = c.getShareFolderFiles(from, pwd)
+		}
+	}
+	if err != nil {
+		return nil, sharePasswordError(shareID, pwd, err)
+	}
+
+	// vip获取文件夹
+	folders := make([]FileOrFolderByShareUrl, 0, len(page.SubFolders)+len(files))
+	for _, floder := range page.SubFolders {
+		folders = append(folders, FileOrFolderByShareUrl{
+			Pwd:      pwd, // 子文件夹优先使用上级提取码
+			ID:       floder.ID,
+			NameAll:  floder.Name,
+			IsFloder: true,
+		})
+	}
+	return append(folders, files...), nil
+}
+
+// 获取分享文件夹中的文件
+func (c *Client) getShareFolderFiles(from map[string]string, pwd string) ([]FileOrFolderByShareUrl, error) {
+	files := make([]FileOrFolderByShareUrl, 0)
+	from["pwd"] = pwd
+	for page := 1; ; page++ {
+		from["pg"] = strconv.Itoa(page)
+		var resp FileOrFolderByShareUrlResp
+		_, err := c.Post(MustUrlJoin(c.ShareUrl, "/filemoreajax.php"), func(req *resty.Request) { req.SetFormData(from) }, &resp)
+		if err != nil {
+			return nil, err
+		}
+		// 提取码错误时部分情况返回空列表
+		if len(resp.Text) == 0 && isWrongPasswordInfo(resp.Info) {
+			return nil, fmt.Errorf("%w: %s", ErrWrongPassword, resp.Info)
+		}
+		// 文件夹中的文件加密
+		for i := 0; i < len(resp.Text); i++ {
+			resp.Text[i].Pwd = pwd
+		}
+		if len(resp.Text) == 0 {
+			break
+		}
+		files = append(files, resp.Text...)
+	}
+	return files, nil
+}
+
+// page 为 sharePageData 的解析结果
+func (c *Client) getFilesByShareUrl(shareID, pwd string, sharePageData string, page *SharePage) (*FileOrFolderByShareUrl, error) {
+	var (
+		param       map[string]string
+		downloadUrl string
+		file        FileOrFolderByShareUrl
+	)
+
+	// 删除注释
+	sharePageData = RemoveNotes(sharePageData)
+	sharePageData = RemoveJSComment(sharePageData)
+
+	// 需要密码
+	if page.NeedPassword {
+		sharePageData, err := getJSFunctionByName(sharePageData, "down_p")
+		if err != nil {
+			return nil, err
+		}
+		param, err = htmlJsonToMap(sharePageData)
+		if err != nil {
+			return nil, err
+		}
+		param["p"] = pwd
+
+		fileIDs := findFileIDReg.FindStringSubmatch(sharePageData)
+		var fileID string
+		if len(fileIDs) > 1 {
+			fileID = fileIDs[1]
+		} else {
+			return nil, fmt.Errorf("%w: not find file id", ErrTemplateChanged)
+		}
+
+		var resp FileShareInfoAndUrlResp[string]
+		_, err = c.Post(MustUrlJoin(c.ShareUrl, "/ajaxm.php"), func(req *resty.Request) {
+			req.SetFormData(param).SetQueryParam("file", fileID)
+		}, &resp)
+		if err == nil && resp.URL == "" && isWrongPasswordInfo(resp.Inf) {
+			err = fmt.Errorf("%w: %s", ErrWrongPassword, resp.Inf)
+		}
+		if err != nil {
+			return nil, sharePasswordError(shareID, pwd, err)
+		}
+
+		file.NameAll = resp.Inf
+		file.Pwd = pwd
+		downloadUrl = resp.GetDownloadUrl()
+	} else {
+		name, err := page.GetName()
+		if err != nil {
+			return nil, err
+		}
+		iframeSrc, err := page.GetIframeSrc()
+		if err != nil {
+			log.Errorf("lanzou: err => not find file page param ,data => %s\n", sharePageData)
+			return nil, err
+		}
+
+		data, err := c.Get(joinURL(c.ShareUrl, iframeSrc), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		nextPageData := RemoveNotes(string(data))
+		param, err = htmlJsonToMap(nextPageData)
+		if err != nil {
+			return nil, err
+		}
+
+		fileIDs := findFileIDReg.FindStringSubmatch(nextPageData)
+		var fileID string
+		if len(fileIDs) > 1 {
+			fileID = fileIDs[1]
+		} else {
+			return nil, fmt.Errorf("%w: not find file id", ErrTemplateChanged)
+		}
+
+		var resp FileShareInfoAndUrlResp[int]
+		_, err = c.Post(MustUrlJoin(c.ShareUrl, "/ajaxm.php"), func(req *resty.Request) {
+			req.SetFormData(param).SetQueryParam("file", fileID)
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		downloadUrl = resp.GetDownloadUrl()
+		file.NameAll = name
+	}
+
+	if downloadUrl == "" {
+		return nil, fmt.Errorf("%w: download url is null", ErrTemplateChanged)
+	}
+
+	file.Size = page.Size
+	file.ID = shareID
+	file.Time = page.Time
+
+	// 重定向获取真实链接
+	resp, err := c.ClientNotRedirect.R().
+		SetHeaders(map[string]string{
+			"Accept-Language": "zh-CN,zh;q=0.9,en;q=0.8,en-GB;q=0.7,en-US;q=0.6",
+		}).
+		SetCookie(&http.Cookie{
+			Name:  "down_ip",
+			Value: "1",
+		}).Get(downloadUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode() {
+	case 301, 302:
+		file.Url = resp.Header().Get("Location")
+	case 200:
+		param, err = htmlJsonToMap(resp.String())
+		if err != nil {
+			return nil, err
+		}
+		param["el"] = "2"
+
+		data, err := c.Post(MustUrlJoin(c.ShareUrl, "/ajax.php"), func(req *resty.Request) {
+			req.SetFormData(param).SetCookie(&http.Cookie{
+				Name:  "down_ip",
+				Value: "1",
+			})
+		}, nil)
+		if err != nil {
+			return nil, err
+		}
+		file.Url = gjson.GetBytes(data, "url").String()
+	default:
+		s := resp.String()
+		maxLen := 64
+		if len(s) > maxLen {
+			s = s[:maxLen] + "..."
+		}
+		return nil, fmt.Errorf("get download err: code %d content %d(%s)", resp.StatusCode(), len(resp.Bytes()), s)
+	}
+	return &file, nil
+}
@@ -0,0 +1,184 @@
+package lanzou
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func shareNames(files []FileOrFolderByShareUrl) []string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.GetName())
+	}
+	return names
+}
+
+func TestShareFolder(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.client()
+	dir := f.addFolder("-1", "docs", "")
+	sub := f.addFolder(dir, "sub", "")
+	for _, name := range []string{"a.zip", "b.zip", "c.zip"} {
+		f.addFile(dir, name, "", "data")
+	}
+
+	files, err := c.GetFileOrFolderByShareUrl("b"+dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(shareNames(files), ","); got != "sub,a.zip,b.zip,c.zip" {
+		t.Fatalf("share files = %s", got)
+	}
+	if !files[0].IsDir() || files[0].ID != "b"+sub {
+		t.Fatalf("subfolder = %+v", files[0])
+	}
+	if files[1].IsDir() || files[1].GetSize() != 4 {
+		t.Fatalf("file = %+v", files[1])
+	}
+}
+
+func TestShareFolderPassword(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.client()
+	dir := f.addFolder("-1", "docs", "1234")
+	f.addFile(dir, "a.zip", "", "data")
+
+	if _, err := c.GetFileOrFolderByShareUrl("b"+dir, ""); !errors.Is(err, ErrSharePasswordRequired) {
+		t.Fatalf("no password: got %v, want ErrSharePasswordRequired", err)
+	}
+	if _, err := c.GetFileOrFolderByShareUrl("b"+dir, "0000"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("wrong password: got %v, want ErrWrongPassword", err)
+	}
+
+	files, err := c.GetFileOrFolderByShareUrl("b"+dir, "1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Pwd != "1234" {
+		t.Fatalf("files = %+v", files)
+	}
+
+	// 上级提取码错误时使用配置的子文件夹提取码
+	c.FolderPasswords["b"+dir] = "1234"
+	if _, err := c.GetFileOrFolderByShareUrl("b"+dir, "0000"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestShareFile(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.client()
+	id := f.addFile("-1", "a.zip", "", "hello")
+
+	files, err := c.GetFileOrFolderByShareUrl("i"+id, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].GetName() != "a.zip" || files[0].GetSize() != 5 {
+		t.Fatalf("files = %+v", files)
+	}
+	assertDownload(t, c, files[0].Url, "hello")
+}
+
+// 页面中没有文件名时返回错误,不返回空名称的文件
+func TestShareFileNameMissing(t *testing.T) {
+	f := newFakeLanZou(t)
+	id := f.addFile("-1", "", "", "hello")
+	if _, err := f.client().GetFileOrFolderByShareUrl("i"+id, ""); !errors.Is(err, ErrSharePageFieldMissing) || !errors.Is(err, ErrTemplateChanged) {
+		t.Fatalf("got %v, want ErrSharePageFieldMissing", err)
+	}
+}
+
+func TestShareFilePassword(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.client()
+	id := f.addFile("-1", "a.zip", "abcd", "hello")
+
+	if _, err := c.GetFilesByShareUrl("i"+id, ""); !errors.Is(err, ErrSharePasswordRequired) {
+		t.Fatalf("no password: got %v, want ErrSharePasswordRequired", err)
+	}
+	if _, err := c.GetFilesByShareUrl("i"+id, "0000"); !errors.Is(err, ErrWrongPassword) {
+		t.Fatalf("wrong password: got %v, want ErrWrongPassword", err)
+	}
+
+	file, err := c.GetFilesByShareUrl("i"+id, "abcd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.GetName() != "a.zip" || file.Pwd != "abcd" {
+		t.Fatalf("file = %+v", file)
+	}
+	assertDownload(t, c, file.Url, "hello")
+}
+
+func TestShareMissing(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.client()
+	if _, err := c.GetFileOrFolderByShareUrl("i999", ""); !errors.Is(err, ErrFileShareCancel) {
+		t.Fatalf("cancelled file: got %v, want ErrFileShareCancel", err)
+	}
+	if _, err := c.GetFileOrFolderByShareUrl("b999", ""); !errors.Is(err, ErrFileNotExist) {
+		t.Fatalf("missing folder: got %v, want ErrFileNotExist", err)
+	}
+}
+
+// 登录后通过文件ID获取分享信息再解析下载链接
+func TestShareOwnFile(t *testing.T) {
+	f := newFakeLanZou(t)
+	c := f.login(t)
+	id := f.addFile("-1", "a.zip", "", "hello")
+
+	share, err := c.GetFileShareUrlByID(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := c.GetFilesByShareUrl(share.FID, share.Pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertDownload(t, c, file.Url, "hello")
+
+	size, _ := c.GetFileRealInfo(file.Url)
+	if size != 5 {
+		t.Fatalf("real size = %d", size)
+	}
+}
+
+func assertDownload(t *testing.T, c *Client, url, want string) {
+	t.Helper()
+	if GetExpirationTime(url) <= 0 {
+		t.Fatalf("download url %s has no expiration", url)
+	}
+	resp, err := c.Client.R().SetDoNotParseResponse(true).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode() != http.StatusOK || string(data) != want {
+		t.Fatalf("download %s: status %d, body %q", url, resp.StatusCode(), data)
+	}
+}
+
+func TestParseShareLink(t *testing.T) {
+	for _, tt := range []struct{ link, host, id, pwd string }{
+		{"iAbc123", "", "iAbc123", ""},
+		{"b0abc 密码:1234", "", "b0abc", "1234"},
+		{"https://wwop.lanzoul.com/iAbc123", "https://wwop.lanzoul.com", "iAbc123", ""},
+		{"https://wwop.lanzoul.com/b0abc?pwd=x7q2", "https://wwop.lanzoul.com", "b0abc", "x7q2"},
+		{"xxx.lanzoui.com/iAbc123", "https://xxx.lanzoui.com", "iAbc123", ""},
+		{"链接：https://x.lanzoux.com/iAbc 密码:1234", "https://x.lanzoux.com", "iAbc", "1234"},
+		{"链接: x.lanzoux.com/iAbc\n提取码：ab12", "https://x.lanzoux.com", "iAbc", "ab12"},
+		{"http://pan.example.com/iAbc/", "http://pan.example.com", "iAbc", ""},
+		{"链接：", "", "", ""},
+		{"xxx.lanzoui.com", "", "", ""},
+	} {
+		host, id, pwd := ParseShareLink(tt.link)
+		if host != tt.host || id != tt.id || pwd != tt.pwd {
+			t.Errorf("ParseShareLink(%q) = %q %q %q, want %q %q %q", tt.link, host, id, pwd, tt.host, tt.id, tt.pwd)
+		}
+	}
+}
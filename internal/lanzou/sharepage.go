@@ -0,0 +1,272 @@
+package lanzou
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+/*
+分享页面解析
+基于 HTML 词法分析提取页面信息,提取失败的字段再使用原有正则兜底
+*/
+
+var ErrSharePageFieldMissing = newLanZouError("share page field missing", ErrTemplateChanged)
+
+type SharePageKind int
+
+const (
+	SharePageKindUnknown SharePageKind = iota
+	SharePageKindFile
+	SharePageKindFolder
+)
+
+// 分享页面
+type SharePage struct {
+	Kind         SharePageKind
+	Name         string
+	Size         string
+	Time         string
+	Description  string
+	IframeSrc    string
+	SubFolders   []SharePageFolder
+	NeedPassword bool
+}
+
+// 分享页面中的子文件夹
+type SharePageFolder struct {
+	ID   string
+	Name string
+}
+
+// 下载必需的字段,缺失时返回 ErrSharePageFieldMissing
+func (p *SharePage) GetName() (string, error)      { return sharePageField("name", p.Name) }
+func (p *SharePage) GetIframeSrc() (string, error) { return sharePageField("iframe src", p.IframeSrc) }
+
+func sharePageField(name, value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrSharePageFieldMissing, name)
+	}
+	return value, nil
+}
+
+var findFileNameVarReg = regexp.MustCompile(`var filename = '(.+?)';`)
+
+// html 元素,只记录解析需要的属性
+type htmlElem struct {
+	tag   string
+	id    string
+	class string
+	style string
+}
+
+func (e htmlElem) has(name string) bool {
+	return e.id == name || strings.Contains(" "+e.class+" ", " "+name+" ")
+}
+
+// 解析分享页面
+func ParseSharePage(data string) *SharePage {
+	var (
+		page     SharePage
+		stack    []htmlElem
+		texts    []string
+		isFile   bool
+		isFolder bool
+		folder   *SharePageFolder // 正在解析的子文件夹链接
+		descNext bool             // 下一段文本为描述
+	)
+
+	// 是否在子文件夹列表中
+	inFolderList := func() bool {
+		for _, e := range stack {
+			if e.has("folderlink") || e.has("mbxfolder") {
+				return true
+			}
+		}
+		return false
+	}
+
+	z := html.NewTokenizer(strings.NewReader(data))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag, hasAttr := z.TagName()
+			elem := htmlElem{tag: string(tag)}
+			var href, src string
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				switch string(key) {
+				case "id":
+					elem.id = string(val)
+				case "class":
+					elem.class = string(val)
+				case "style":
+					elem.style = string(val)
+				case "href":
+					href = string(val)
+				case "src":
+					src = string(val)
+				}
+			}
+
+			switch {
+			case elem.has("pwdload") || elem.has("passwddiv"):
+				page.NeedPassword = true
+			case elem.has("fileinfo") || elem.id == "file":
+				isFile = true
+			case elem.id == "infos":
+				isFolder = true
+			}
+			if elem.tag == "iframe" && src != "" && page.IframeSrc == "" {
+				page.IframeSrc = src
+			}
+			if elem.tag == "a" && strings.HasPrefix(href, "/") && len(href) > 1 && inFolderList() {
+				folder = &SharePageFolder{ID: href[1:]}
+			}
+
+			if tt == html.StartTagToken && !isVoidElement(elem.tag) {
+				stack = append(stack, elem)
+			}
+		case html.EndTagToken:
+			tag, _ := z.TagName()
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].tag == string(tag) {
+					stack = stack[:i]
+					break
+				}
+			}
+			if string(tag) == "a" && folder != nil {
+				if folder.Name != "" {
+					page.SubFolders = append(page.SubFolders, *folder)
+				}
+				folder = nil
+			}
+		case html.TextToken:
+			text := strings.TrimSpace(string(z.Text()))
+			if text == "" {
+				continue
+			}
+			var parent htmlElem
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			}
+
+			if parent.tag == "script" {
+				if names := findFileNameVarReg.FindStringSubmatch(text); len(names) == 2 && page.Name == "" {
+					page.Name = names[1]
+				}
+				continue
+			}
+			if parent.tag == "style" {
+				continue
+			}
+
+			switch {
+			case page.Name != "":
+			case parent.tag == "title":
+				if name, ok := strings.CutSuffix(text, " - 蓝奏云"); ok {
+					page.Name = name
+				}
+			case parent.id == "filenajax" || parent.has("filethetext"):
+				page.Name = text
+			case parent.tag == "div" && strings.HasPrefix(parent.style, "font-size"):
+				page.Name = text
+			}
+
+			if folder != nil && (folder.Name == "" || parent.has("filename")) {
+				folder.Name = text
+			}
+
+			if descNext {
+				page.Description = text
+				descNext = false
+			}
+			if desc, ok := strings.CutPrefix(text, "文件描述"); ok {
+				isFile = true
+				desc = strings.TrimSpace(strings.TrimLeft(desc, ":："))
+				if desc != "" {
+					page.Description = desc
+				} else {
+					descNext = true
+				}
+			}
+			texts = append(texts, text)
+		}
+	}
+
+	text := strings.Join(texts, " ")
+	if sizes := sizeFindReg.FindStringSubmatch(text); len(sizes) == 2 {
+		page.Size = sizes[1]
+	}
+	page.Time = timeFindReg.FindString(text)
+
+	switch {
+	case isFile:
+		page.Kind = SharePageKindFile
+	case isFolder:
+		page.Kind = SharePageKindFolder
+	}
+
+	fallbackSharePage(&page, data)
+	return &page
+}
+
+// 使用原有正则补全未解析到的字段
+func fallbackSharePage(page *SharePage, data string) {
+	if page.Kind == SharePageKindUnknown {
+		if isFileReg.MatchString(data) {
+			page.Kind = SharePageKindFile
+		} else if isFolderReg.MatchString(data) {
+			page.Kind = SharePageKindFolder
+		}
+	}
+	if page.Name == "" {
+		names := nameFindReg.FindStringSubmatch(data)
+		if len(names) > 1 {
+			for _, name := range names[1:] {
+				if name != "" {
+					page.Name = name
+					break
+				}
+			}
+		}
+	}
+	if page.Size == "" {
+		if sizes := sizeFindReg.FindStringSubmatch(data); len(sizes) == 2 {
+			page.Size = sizes[1]
+		}
+	}
+	if page.Time == "" {
+		page.Time = timeFindReg.FindString(data)
+	}
+	if page.IframeSrc == "" {
+		if urlpaths := findDownPageParamReg.FindStringSubmatch(data); len(urlpaths) == 2 {
+			page.IframeSrc = urlpaths[1]
+		}
+	}
+	if len(page.SubFolders) == 0 {
+		for _, floder := range findSubFolderReg.FindAllStringSubmatch(data, -1) {
+			if len(floder) == 3 {
+				page.SubFolders = append(page.SubFolders, SharePageFolder{ID: floder[1], Name: floder[2]})
+			}
+		}
+	}
+	if !page.NeedPassword {
+		page.NeedPassword = isPasswordPage(data)
+	}
+}
+
+func isVoidElement(tag string) bool {
+	switch tag {
+	case "area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "source", "track", "wbr":
+		return true
+	}
+	return false
+}
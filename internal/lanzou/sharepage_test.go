@@ -0,0 +1,60 @@
+package lanzou
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSharePage(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want SharePage
+	}{
+		{
+			name: "entity in title",
+			data: `<html><head><title>a&lt;b&#39;c.zip - 蓝奏云</title></head><body>
+<div class="fileinfo"><span class="p7">文件大小：</span>12.3 M<br><span class="p7">上传时间：</span>2024-03-15<br></div>
+<iframe class="ifr2" src="/fn?abc" frameborder="0"></iframe>
+</body></html>`,
+			want: SharePage{Kind: SharePageKindFile, Name: "a<b'c.zip", Size: "12.3 M", Time: "2024-03-15", IframeSrc: "/fn?abc"},
+		},
+		{
+			name: "quote in filename var",
+			data: `<html><head><title>文件</title></head><body>
+<div class="passwddiv"><input type="text" id="pwd"></div>
+<script type="text/javascript">
+	var filename = 'it's here.zip';
+	function down_p(){}
+</script>
+</body></html>`,
+			want: SharePage{Kind: SharePageKindUnknown, Name: "it's here.zip", NeedPassword: true},
+		},
+		{
+			name: "password page",
+			data: readTestdata(t, "share/file_pwd/01-get.html"),
+			want: SharePage{Kind: SharePageKindFile, Size: "1.6 M", Time: "2024-05-20", NeedPassword: true},
+		},
+		{
+			name: "folder page",
+			data: readTestdata(t, "share/folder/01-get.html"),
+			want: SharePage{Kind: SharePageKindFolder, SubFolders: []SharePageFolder{{ID: "b0a1b2c3d", Name: "驱动"}, {ID: "b0e4f5g6h", Name: "Linux 版"}}},
+		},
+		{
+			// 脚本输出的元素不是 html 标签,只能由正则取到
+			name: "regex fallback",
+			data: `<html><body><script>
+document.write('<div class="fileinfo"><div id="filenajax">fallback.zip</div>大小：3.5 M<iframe class="ifr2" src="/fn?xyz"></iframe></div>');
+</script></body></html>`,
+			want: SharePage{Kind: SharePageKindFile, Name: "fallback.zip", Size: "3.5 M", IframeSrc: "/fn?xyz"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseSharePage(tt.data)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Fatalf("got  %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package lanzou
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+/*
+分卷上传
+超过单文件大小限制的文件被切分为多个分卷,另附一个清单文件
+清单文件名中记录了原文件大小和分卷大小,列表时无需下载清单即可还原
+*/
+
+const (
+	splitPartExt     = ".lzpart.zip"
+	splitManifestExt = ".lzsplit.txt"
+)
+
+// 清单文件: 原文件名.总大小-分卷大小.lzsplit.txt
+var splitManifestReg = regexp.MustCompile(`^(.+)\.(\d+)-(\d+)` + regexp.QuoteMeta(splitManifestExt) + `$`)
+
+// 分卷文件: 原文件名.序号.lzpart.zip
+var splitPartReg = regexp.MustCompile(`^(.+)\.(\d{3,})` + regexp.QuoteMeta(splitPartExt) + `$`)
+
+// 清单文件内容,仅用于人工恢复
+type SplitManifest struct {
+	Name     string   `json:"name"`
+	Size     int64    `json:"size"`
+	PartSize int64    `json:"part_size"`
+	Parts    []string `json:"parts"`
+}
+
+func SplitManifestName(name string, size, partSize int64) string {
+	return fmt.Sprintf("%s.%d-%d%s", name, size, partSize, splitManifestExt)
+}
+
+func SplitPartName(name string, index int) string {
+	return fmt.Sprintf("%s.%03d%s", name, index+1, splitPartExt)
+}
+
+// 合并后的分卷文件
+type SplitFile struct {
+	Manifest FileOrFolder
+	Name     string
+	Size     int64
+	PartSize int64
+	PartIDs  []string
+}
+
+// 将分卷文件合并,缺少分卷的清单和分卷作为普通文件返回
+func GroupSplitFiles(files []FileOrFolder) (splits []SplitFile, rest []FileOrFolder) {
+	type part struct {
+		index int
+		id    string
+	}
+	parts := make(map[string][]part)
+	for _, file := range files {
+		if m := splitPartReg.FindStringSubmatch(file.GetName()); m != nil {
+			index, _ := strconv.Atoi(m[2])
+			parts[m[1]] = append(parts[m[1]], part{index: index, id: file.GetID()})
+		}
+	}
+
+	// 按ID记录已合并的清单和分卷,同名的普通文件不受影响
+	used := make(map[string]bool)
+	for _, file := range files {
+		m := splitManifestReg.FindStringSubmatch(file.GetName())
+		if m == nil {
+			continue
+		}
+		size, _ := strconv.ParseInt(m[2], 10, 64)
+		partSize, _ := strconv.ParseInt(m[3], 10, 64)
+		if partSize <= 0 {
+			continue
+		}
+		count := int((size + partSize - 1) / partSize)
+
+		partIDs := make([]string, count)
+		for _, p := range parts[m[1]] {
+			if p.index >= 1 && p.index <= count {
+				partIDs[p.index-1] = p.id
+			}
+		}
+		if len(parts[m[1]]) != count || slices.Contains(partIDs, "") {
+			continue
+		}
+		// 重复的清单只合并一次
+		if slices.ContainsFunc(partIDs, func(id string) bool { return used[id] }) {
+			continue
+		}
+
+		splits = append(splits, SplitFile{
+			Manifest: file,
+			Name:     m[1],
+			Size:     size,
+			PartSize: partSize,
+			PartIDs:  partIDs,
+		})
+		used[file.GetID()] = true
+		for _, id := range partIDs {
+			used[id] = true
+		}
+	}
+
+	for _, file := range files {
+		if !used[file.GetID()] {
+			rest = append(rest, file)
+		}
+	}
+	return splits, rest
+}
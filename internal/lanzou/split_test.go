@@ -0,0 +1,109 @@
+package lanzou
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestSplitNames(t *testing.T) {
+	if got := SplitPartName("big.iso", 0); got != "big.iso.001.lzpart.zip" {
+		t.Fatalf("part name = %s", got)
+	}
+	if got := SplitPartName("big.iso", 1234); got != "big.iso.1235.lzpart.zip" {
+		t.Fatalf("part name = %s", got)
+	}
+	if got := SplitManifestName("big.iso", 250, 100); got != "big.iso.250-100.lzsplit.txt" {
+		t.Fatalf("manifest name = %s", got)
+	}
+	// 生成的名称能被识别
+	if m := splitPartReg.FindStringSubmatch(SplitPartName("a.b.001", 9)); m == nil || m[1] != "a.b.001" || m[2] != "010" {
+		t.Fatalf("part match = %v", m)
+	}
+	if m := splitManifestReg.FindStringSubmatch(SplitManifestName("a.1-2", 3, 1)); m == nil || m[1] != "a.1-2" {
+		t.Fatalf("manifest match = %v", m)
+	}
+}
+
+// 文件列表,ID 为在列表中的序号,从 1 开始
+func splitListing(names ...string) []FileOrFolder {
+	files := make([]FileOrFolder, len(names))
+	for i, name := range names {
+		files[i] = FileOrFolder{ID: strconv.Itoa(i + 1), NameAll: name}
+	}
+	return files
+}
+
+func restNames(rest []FileOrFolder) []string {
+	names := make([]string, len(rest))
+	for i, f := range rest {
+		names[i] = f.GetName()
+	}
+	return names
+}
+
+func TestGroupSplitFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		partIDs [][]string // 每个合并结果的分卷ID
+		rest    []string
+	}{
+		{
+			name:    "complete",
+			files:   []string{"big.iso.001.lzpart.zip", "big.iso.002.lzpart.zip", "big.iso.003.lzpart.zip", "big.iso.250-100.lzsplit.txt", "a.zip"},
+			partIDs: [][]string{{"1", "2", "3"}},
+			rest:    []string{"a.zip"},
+		},
+		{
+			name:    "out of order",
+			files:   []string{"big.iso.250-100.lzsplit.txt", "big.iso.003.lzpart.zip", "big.iso.001.lzpart.zip", "big.iso.002.lzpart.zip"},
+			partIDs: [][]string{{"3", "4", "2"}},
+		},
+		{
+			// 同名的普通文件不能被隐藏
+			name:    "plain file with the same name",
+			files:   []string{"big.iso.001.lzpart.zip", "big.iso.002.lzpart.zip", "big.iso.200-100.lzsplit.txt", "big.iso"},
+			partIDs: [][]string{{"1", "2"}},
+			rest:    []string{"big.iso"},
+		},
+		{
+			name:  "missing part",
+			files: []string{"big.iso.001.lzpart.zip", "big.iso.003.lzpart.zip", "big.iso.250-100.lzsplit.txt"},
+			rest:  []string{"big.iso.001.lzpart.zip", "big.iso.003.lzpart.zip", "big.iso.250-100.lzsplit.txt"},
+		},
+		{
+			name:  "extra part",
+			files: []string{"big.iso.001.lzpart.zip", "big.iso.002.lzpart.zip", "big.iso.003.lzpart.zip", "big.iso.200-100.lzsplit.txt"},
+			rest:  []string{"big.iso.001.lzpart.zip", "big.iso.002.lzpart.zip", "big.iso.003.lzpart.zip", "big.iso.200-100.lzsplit.txt"},
+		},
+		{
+			name:  "no manifest",
+			files: []string{"big.iso.001.lzpart.zip", "big.iso.002.lzpart.zip"},
+			rest:  []string{"big.iso.001.lzpart.zip", "big.iso.002.lzpart.zip"},
+		},
+		{
+			// 重复上传的清单只合并一次,另一个作为普通文件
+			name:    "duplicate manifest",
+			files:   []string{"big.iso.001.lzpart.zip", "big.iso.200-100.lzsplit.txt", "big.iso.002.lzpart.zip", "big.iso.200-100.lzsplit.txt"},
+			partIDs: [][]string{{"1", "3"}},
+			rest:    []string{"big.iso.200-100.lzsplit.txt"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			splits, rest := GroupSplitFiles(splitListing(tt.files...))
+			if len(splits) != len(tt.partIDs) {
+				t.Fatalf("got %d split files, want %d", len(splits), len(tt.partIDs))
+			}
+			for i, split := range splits {
+				if split.Name != "big.iso" || !slices.Equal(split.PartIDs, tt.partIDs[i]) {
+					t.Errorf("split %d = %s %v, want big.iso %v", i, split.Name, split.PartIDs, tt.partIDs[i])
+				}
+			}
+			if got := restNames(rest); !slices.Equal(got, tt.rest) {
+				t.Errorf("rest = %v, want %v", got, tt.rest)
+			}
+		})
+	}
+}
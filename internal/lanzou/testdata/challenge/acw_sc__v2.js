@@ -0,0 +1,23 @@
+// 用 node 执行 acw_sc__v2.html 中的脚本,输出页面写入的 acw_sc__v2,结果保存在 acw_sc__v2.want
+//
+//	node acw_sc__v2.js > acw_sc__v2.want
+const fs = require('fs');
+const vm = require('vm');
+
+const html = fs.readFileSync(__dirname + '/acw_sc__v2.html', 'utf8');
+const script = html.match(/<script>([\s\S]*?)<\/script>/)[1];
+
+let cookie = '';
+const document = {
+	set cookie(v) { cookie = v; },
+	get cookie() { return cookie; },
+	location: { reload() {} },
+};
+vm.runInNewContext(script, { window: {}, document, Date });
+
+const m = cookie.match(/^acw_sc__v2=([^;]*)/);
+if (!m) {
+	console.error('page did not set acw_sc__v2: ' + cookie);
+	process.exit(1);
+}
+console.log(m[1]);
@@ -1,19 +1,10 @@
-package main
+package lanzou
 
 import (
-	"errors"
 	"fmt"
-
-	"github.com/OpenListTeam/openlist-wasi-plugin-driver/adapter"
-	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
-
 	"time"
 )
 
-var ErrFileShareCancel = errors.New("file sharing cancellation")
-var ErrFileNotExist = errors.New("file does not exist")
-var ErrCookieExpiration = errors.New("cookie expiration")
-
 type RespText[T any] struct {
 	Text T `json:"text"`
 }
@@ -51,20 +42,6 @@ type FileOrFolder struct {
 	time *time.Time `json:"-"`
 }
 
-func (f *FileOrFolder) ToObject() drivertypes.Object {
-	return drivertypes.Object{
-		ID:       f.GetID(),
-		Name:     f.GetName(),
-		Size:     f.GetSize(),
-		IsFolder: f.IsDir(),
-		Modified: drivertypes.Duration(f.ModTime().UnixNano()),
-		Created:  drivertypes.Duration(f.CreateTime().UnixNano()),
-		Extra: adapter.ExtraFormMap(map[string]string{
-			"type": "0",
-		}),
-	}
-}
-
 func (f *FileOrFolder) CreateTime() time.Time {
 	return f.ModTime()
 }
@@ -79,7 +56,7 @@ func (f *FileOrFolder) GetName() string {
 	if f.IsDir() {
 		return f.Name
 	}
-	return f.NameAll
+	return UnwrapName(f.NameAll)
 }
 func (f *FileOrFolder) GetPath() string { return "" }
 func (f *FileOrFolder) GetSize() int64 {
@@ -116,6 +93,8 @@ type FileShare struct {
 
 /* 分享类型为文件夹 */
 type FileOrFolderByShareUrlResp struct {
+	Zt   int                      `json:"zt"`
+	Info string                   `json:"info"`
 	Text []FileOrFolderByShareUrl `json:"text"`
 }
 type FileOrFolderByShareUrl struct {
@@ -142,28 +121,17 @@ type FileOrFolderByShareUrl struct {
 	time *time.Time `json:"-"`
 }
 
-func (f *FileOrFolderByShareUrl) ToObject() drivertypes.Object {
-	return drivertypes.Object{
-		ID:       f.GetID(),
-		Name:     f.GetName(),
-		Size:     f.GetSize(),
-		IsFolder: f.IsDir(),
-		Modified: drivertypes.Duration(f.ModTime().UnixNano()),
-		Created:  drivertypes.Duration(f.CreateTime().UnixNano()),
-		Extra: adapter.ExtraFormMap(map[string]string{
-			"type": "1",
-			"fid":  f.ID,
-			"pwd":  f.Pwd,
-		}),
-	}
-}
-
 func (f *FileOrFolderByShareUrl) CreateTime() time.Time {
 	return f.ModTime()
 }
 
-func (f *FileOrFolderByShareUrl) GetID() string   { return f.ID }
-func (f *FileOrFolderByShareUrl) GetName() string { return f.NameAll }
+func (f *FileOrFolderByShareUrl) GetID() string { return f.ID }
+func (f *FileOrFolderByShareUrl) GetName() string {
+	if f.IsDir() {
+		return f.NameAll
+	}
+	return UnwrapName(f.NameAll)
+}
 func (f *FileOrFolderByShareUrl) GetPath() string { return "" }
 func (f *FileOrFolderByShareUrl) GetSize() int64 {
 	if f.size == nil {
@@ -183,6 +151,7 @@ func (f *FileOrFolderByShareUrl) ModTime() time.Time {
 
 // 获取下载链接的响应
 type FileShareInfoAndUrlResp[T string | int] struct {
+	Zt  int    `json:"zt"`
 	Dom string `json:"dom"`
 	URL string `json:"url"`
 	Inf T      `json:"inf"`
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"openlist-lanzou-plugin/internal/core"
+
+	openlistwasiplugindriver "github.com/OpenListTeam/openlist-wasi-plugin-driver"
+	"resty.dev/v3"
+)
+
+// 根据请求地址选择限速分组
+func (d *LanZou) limitBudget(rawURL string) string {
+	return core.LimitBudget(rawURL, d.BaseUrl, d.ShareUrl)
+}
+
+// 为客户端添加限速
+func (d *LanZou) withLimiter(client *resty.Client) *resty.Client {
+	return client.
+		AddRequestMiddleware(func(c *resty.Client, req *resty.Request) error {
+			return d.limiter.Wait(req.Context(), d.limitBudget(req.URL))
+		}).
+		AddResponseMiddleware(func(c *resty.Client, resp *resty.Response) error {
+			budget := d.limitBudget(resp.Request.URL)
+			if interval := d.limiter.Observe(budget, core.IsBusyResponse(resp.Bytes())); interval > 0 {
+				openlistwasiplugindriver.Warnf("lanzou: %s requests busy, interval => %s\n", budget, interval)
+			}
+			return nil
+		})
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"openlist-lanzou-plugin/internal/core"
+	"openlist-lanzou-plugin/internal/lanzou"
+
 	openlistwasiplugindriver "github.com/OpenListTeam/openlist-wasi-plugin-driver"
 )
 
@@ -10,7 +13,10 @@ type Addition struct {
 	Account  string `json:"account"`
 	Password string `json:"password"`
 
-	Cookie string `json:"cookie"`
+	Cookie       string `json:"cookie"`
+	CookieImport string `json:"cookie_import"`
+	Session      string `json:"session"`
+	LoginVerify  string `json:"login_verify"`
 
 	openlistwasiplugindriver.RootID
 
@@ -19,6 +25,18 @@ type Addition struct {
 	ShareUrl       string `json:"share_url"`
 	UserAgent      string `json:"user_agent"`
 	RepairFileInfo bool   `json:"repair_file_info"`
+	ListCacheTTL   int64  `json:"list_cache_ttl"`
+
+	BaseRateLimit  float64 `json:"base_rate_limit"`
+	ShareRateLimit float64 `json:"share_rate_limit"`
+
+	SplitUpload bool  `json:"split_upload"`
+	SplitSize   int64 `json:"split_size"`
+
+	WrapExt string `json:"wrap_ext"`
+
+	Shares          string `json:"shares"`
+	FolderPasswords string `json:"folder_passwords"`
 }
 
 func (a *Addition) IsCookie() bool {
@@ -29,6 +47,22 @@ func (a *Addition) IsAccount() bool {
 	return a.Type == "account"
 }
 
+func (a *Addition) GetShares() []core.ShareEntry {
+	return core.ParseShares(a.Shares)
+}
+
+func (a *Addition) checkShares() error {
+	return core.CheckShares(a.Shares)
+}
+
+func (a *Addition) GetFolderPasswords() map[string]string {
+	return core.ParseFolderPasswords(a.FolderPasswords)
+}
+
+func (a *Addition) GetLoginVerify() (lanzou.LoginVerify, bool) {
+	return core.ParseLoginVerify(a.LoginVerify)
+}
+
 func init() {
 	openlistwasiplugindriver.RegisterDriver(&LanZou{})
 }
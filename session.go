@@ -0,0 +1,214 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"openlist-lanzou-plugin/internal/core"
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	openlistwasiplugindriver "github.com/OpenListTeam/openlist-wasi-plugin-driver"
+	"resty.dev/v3"
+	"resty.dev/v3/cookiejar"
+)
+
+/*
+会话持久化
+登录后将 cookie 和 uid/vei 保存到配置中,重启时先校验保存的会话,失效后才重新登录
+频繁使用密码登录会触发蓝奏云的风控
+cookie 模式下记录响应中刷新的 cookie 并写回配置,根据过期时间提前警告
+*/
+
+// 保存当前会话到配置
+func (d *LanZou) saveSession() {
+	d.sessionMu.Lock()
+	defer d.sessionMu.Unlock()
+
+	vei, uid := d.api.Params()
+	session := core.Session{
+		Account: d.Account,
+		Cookie:  d.Cookie,
+		Uid:     uid,
+		Vei:     vei,
+	}
+	if d.IsAccount() {
+		u, err := url.Parse(d.BaseUrl)
+		if err != nil {
+			return
+		}
+		session.Cookie = lanzou.CookieToString(d.api.CookieJar.Cookies(u))
+	}
+	if !d.sessionExpires.IsZero() {
+		session.Expires = d.sessionExpires.Unix()
+	}
+	d.Session = session.String()
+	if err := d.SaveConfig(&d.Addition); err != nil {
+		openlistwasiplugindriver.Warnf("lanzou: save session failed: %v\n", err)
+	}
+}
+
+// cookie 模式恢复过期时间,优先使用导入 cookie 中的过期时间
+// 保存的过期时间在 cookie 被修改后作废
+func (d *LanZou) restoreCookieExpiry(cookies []*http.Cookie) {
+	expires := core.SessionExpiry(cookies)
+	if session, ok := core.ParseSession(d.Session); expires.IsZero() && ok && session.Cookie == d.Cookie && session.Expires != 0 {
+		expires = time.Unix(session.Expires, 0)
+	}
+	if expires.IsZero() {
+		return
+	}
+	d.sessionMu.Lock()
+	d.sessionExpires = expires
+	d.sessionMu.Unlock()
+	d.checkSessionExpiry()
+}
+
+// 记录响应中刷新的会话 cookie,cookie 模式下会话过期后不再发出请求
+func (d *LanZou) withSessionRefresh(client *resty.Client) *resty.Client {
+	return client.AddRequestMiddleware(func(c *resty.Client, req *resty.Request) error {
+		if !d.IsCookie() || d.limitBudget(req.URL) != core.BudgetBase {
+			return nil
+		}
+		_, expires, _ := d.sessionRemaining()
+		return core.SessionExpiredError(expires)
+	}).AddResponseMiddleware(func(c *resty.Client, resp *resty.Response) error {
+		if resp.Request != nil {
+			d.refreshSession(resp.Request.URL, resp.Cookies())
+		}
+		d.tickSessionExpiry()
+		return nil
+	})
+}
+
+func (d *LanZou) refreshSession(rawURL string, cookies []*http.Cookie) {
+	if (!d.IsCookie() && !d.IsAccount()) || len(cookies) == 0 || d.limitBudget(rawURL) != core.BudgetBase {
+		return
+	}
+
+	d.sessionMu.Lock()
+	changed := false
+	for _, cookie := range cookies {
+		if !core.SessionCookieNames[cookie.Name] {
+			continue
+		}
+		if expires := lanzou.CookieExpiry(cookie); !expires.IsZero() && (d.sessionExpires.IsZero() || lanzou.ExpiryChanged(d.sessionExpires.Unix(), expires)) {
+			d.sessionExpires = expires
+			changed = true
+		}
+		// 删除 cookie 的响应不写回
+		if cookie.MaxAge < 0 || cookie.Value == "" {
+			continue
+		}
+		if d.IsCookie() {
+			if c := lanzou.UpdateCookieConfig(d.Cookie, cookie); c != d.Cookie {
+				d.Cookie = c
+				changed = true
+			}
+		}
+	}
+	d.sessionMu.Unlock()
+
+	if changed {
+		openlistwasiplugindriver.Debugf("lanzou: session cookie refreshed\n")
+		d.saveSession()
+		d.checkSessionExpiry()
+	}
+}
+
+// 会话剩余时间,未知时返回 false
+func (d *LanZou) sessionRemaining() (time.Duration, time.Time, bool) {
+	d.sessionMu.Lock()
+	defer d.sessionMu.Unlock()
+	if d.sessionExpires.IsZero() {
+		return 0, time.Time{}, false
+	}
+	return time.Until(d.sessionExpires), d.sessionExpires, true
+}
+
+// 请求时检查过期时间的最小间隔
+const sessionCheckInterval = time.Minute
+
+// 在请求中定期检查过期时间,长时间运行时也能按时提示
+func (d *LanZou) tickSessionExpiry() {
+	d.sessionMu.Lock()
+	due := !d.sessionExpires.IsZero() && time.Since(d.sessionCheckedAt) >= sessionCheckInterval
+	if due {
+		d.sessionCheckedAt = time.Now()
+	}
+	d.sessionMu.Unlock()
+	if due {
+		d.checkSessionExpiry()
+	}
+}
+
+// 根据剩余时间输出逐级升高的警告,同一等级只提示一次
+func (d *LanZou) checkSessionExpiry() {
+	remaining, expires, ok := d.sessionRemaining()
+	if !ok {
+		return
+	}
+	level := core.SessionWarnLevel(remaining)
+
+	d.sessionMu.Lock()
+	last := d.sessionWarnLevel
+	d.sessionWarnLevel = level
+	d.sessionMu.Unlock()
+	if level <= last {
+		return
+	}
+
+	switch {
+	case level == len(core.SessionWarnLevels):
+		openlistwasiplugindriver.Errorf("lanzou: session cookie expired at %s, please update the cookie\n", expires.Format(time.DateTime))
+	case level >= 2:
+		openlistwasiplugindriver.Warnf("lanzou: session cookie expires in %s (%s), please update the cookie\n", remaining.Round(time.Minute), expires.Format(time.DateTime))
+	default:
+		openlistwasiplugindriver.Infof("lanzou: session cookie expires in %s (%s)\n", remaining.Round(time.Minute), expires.Format(time.DateTime))
+	}
+}
+
+// 输出会话状态
+func (d *LanZou) logSessionState() {
+	_, uid := d.api.Params()
+	remaining, expires, ok := d.sessionRemaining()
+	if !ok {
+		openlistwasiplugindriver.Infof("lanzou: %s session ready, uid %s, expiry unknown\n", d.Type, uid)
+		return
+	}
+	openlistwasiplugindriver.Infof("lanzou: %s session ready, uid %s, expires in %s (%s)\n", d.Type, uid, remaining.Round(time.Minute), expires.Format(time.DateTime))
+}
+
+// 恢复保存的会话,会话有效时返回 true
+func (d *LanZou) restoreSession() bool {
+	session, ok := core.ParseSession(d.Session)
+	if !ok {
+		return false
+	}
+	// 更换账号后旧会话作废
+	if session.Account != d.Account || session.Cookie == "" {
+		return false
+	}
+
+	cookies, err := cookiejar.ParseCookie(session.Cookie)
+	if err != nil {
+		return false
+	}
+	if err := lanzou.SetCookieToJar(d.api.CookieJar, d.BaseUrl, cookies); err != nil {
+		return false
+	}
+
+	vei, uid, err := d.api.GetVeiAndUid()
+	if err != nil {
+		openlistwasiplugindriver.Infof("lanzou: saved session is invalid, login again: %v\n", err)
+		return false
+	}
+	d.api.SetParams(vei, uid)
+	if session.Expires != 0 {
+		d.sessionMu.Lock()
+		d.sessionExpires = time.Unix(session.Expires, 0)
+		d.sessionMu.Unlock()
+		d.checkSessionExpiry()
+	}
+	return true
+}
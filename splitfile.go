@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"openlist-lanzou-plugin/internal/core"
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"github.com/OpenListTeam/openlist-wasi-plugin-driver/adapter"
+	drivertypes "github.com/OpenListTeam/openlist-wasi-plugin-driver/binding/openlist/plugin-driver/types"
+)
+
+/*
+分卷文件的上传、读取和复制,分卷的计算见 internal/core
+*/
+
+// 分卷大小,单位字节
+func (a *Addition) GetSplitSize() int64 {
+	return core.SplitSize(a.SplitSize)
+}
+
+// 分卷上传
+func (d *LanZou) putSplit(ctx context.Context, folderID, name string, size int64, reader io.Reader) (*drivertypes.Object, error) {
+	return core.PutSplit(ctx, name, size, d.GetSplitSize(), reader,
+		func(ctx context.Context, name string, reader io.Reader) (*drivertypes.Object, error) {
+			return d.upload(ctx, folderID, name, reader)
+		}, d.api.RemoveFile)
+}
+
+// 依次读取分卷,输出指定范围的数据
+func (d *LanZou) linkSplitRange(ctx context.Context, file drivertypes.Object, offset, length uint64, w io.Writer) error {
+	ranges, ok := core.SplitRanges(file, offset, length)
+	if !ok {
+		return adapter.ErrNotSupport
+	}
+	for _, r := range ranges {
+		if err := d.copyFileRange(ctx, r.ID, r.Start, r.End, w); err != nil {
+			return fmt.Errorf("read part %d: %w", r.Index+1, err)
+		}
+	}
+	return nil
+}
+
+// 输出文件[start,end)范围的数据
+func (d *LanZou) copyFileRange(ctx context.Context, fileID string, start, end int64, w io.Writer) error {
+	downURL, err := d.getFileDownloadUrl(fileID)
+	if err != nil {
+		return err
+	}
+
+	resp, err := d.api.Client.R().SetContext(ctx).SetDoNotParseResponse(true).
+		SetHeader("Range", fmt.Sprintf("bytes=%d-%d", start, end-1)).
+		Get(downURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode() {
+	case 206:
+	case 200:
+		// 不支持Range时跳过开头
+		if _, err := io.CopyN(io.Discard, resp.Body, start); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("download status %d", resp.StatusCode())
+	}
+	_, err = io.CopyN(w, resp.Body, end-start)
+	return err
+}
+
+// 复制分卷文件
+func (d *LanZou) copySplit(ctx context.Context, srcObj drivertypes.Object, folderID string) (*drivertypes.Object, error) {
+	entries, _ := core.SplitFileEntries(srcObj, srcObj.Name)
+	_, partSize, _ := core.GetSplitParts(srcObj)
+
+	file := lanzou.SplitFile{
+		Name:     srcObj.Name,
+		Size:     srcObj.Size,
+		PartSize: partSize,
+		PartIDs:  make([]string, 0, len(entries)-1),
+	}
+	for i, entry := range entries {
+		obj, err := d.copyFile(ctx, entry[0], entry[1], folderID)
+		if err != nil {
+			return nil, err
+		}
+		if i < len(entries)-1 {
+			file.PartIDs = append(file.PartIDs, obj.ID)
+		} else {
+			file.Manifest = lanzou.FileOrFolder{ID: obj.ID, NameAll: obj.Name}
+		}
+	}
+	res := core.SplitFileToObject(&file)
+	return &res, nil
+}
@@ -0,0 +1,107 @@
+// lzrecord 录制分享链接的请求,生成 internal/lanzou/testdata/share 下的回放用例
+//
+//	go run ./tools/lzrecord -share https://xxx.lanzoul.com/iAbc123 -pwd 1234 -out internal/lanzou/testdata/share/file_pwd
+//
+// 录制后检查生成的文件,删除不应公开的内容后再提交
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"os"
+	"regexp"
+	"strings"
+
+	"openlist-lanzou-plugin/internal/fixture"
+	"openlist-lanzou-plugin/internal/lanzou"
+
+	"resty.dev/v3"
+)
+
+const userAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func main() {
+	var (
+		share    = flag.String("share", "", "share link or share id")
+		pwd      = flag.String("pwd", "", "share password, overrides the one in the link")
+		shareUrl = flag.String("share-url", "https://wwop.lanzoul.com", "share host used when the link has none")
+		out      = flag.String("out", "", "fixture directory")
+		note     = flag.String("note", "", "note saved in fixture.json")
+		redact   = flag.String("redact", "", "regexp of text to replace with *** before saving")
+	)
+	flag.Parse()
+	if *share == "" || *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := record(*share, *pwd, *shareUrl, *out, *note, *redact); err != nil {
+		fmt.Fprintln(os.Stderr, "lzrecord:", err)
+		os.Exit(1)
+	}
+}
+
+func record(link, pwd, shareUrl, out, note, redact string) error {
+	host, shareID, linkPwd := lanzou.ParseShareLink(link)
+	if shareID == "" {
+		return fmt.Errorf("no share id in %q", link)
+	}
+	if host != "" {
+		shareUrl = host
+	}
+	if pwd == "" {
+		pwd = linkPwd
+	}
+
+	recorder := &fixture.Recorder{Transport: http.DefaultTransport}
+	if redact != "" {
+		reg, err := regexp.Compile(redact)
+		if err != nil {
+			return err
+		}
+		recorder.Sanitize = func(s string) string { return reg.ReplaceAllString(s, "***") }
+	}
+
+	jar, _ := cookiejar.New(nil)
+	newClient := func() *resty.Client {
+		return resty.New().
+			SetTransport(recorder).
+			SetHeader("User-Agent", userAgent).
+			SetHeader("Referer", shareUrl).
+			SetCookieJar(jar)
+	}
+	c := &lanzou.Client{
+		BaseUrl:           shareUrl,
+		ShareUrl:          shareUrl,
+		CookieJar:         jar,
+		Client:            newClient(),
+		ClientNotRedirect: newClient().SetRedirectPolicy(resty.NoRedirectPolicy()),
+		UploadClient:      newClient(),
+		FolderPasswords:   map[string]string{},
+	}
+
+	files, err := c.GetFileOrFolderByShareUrl(shareID, pwd)
+	if err != nil {
+		return err
+	}
+
+	f := &fixture.Fixture{Note: note, ShareID: shareID, Pwd: pwd, Exchanges: recorder.Exchanges()}
+	clean := recorder.Clean
+	for _, file := range files {
+		f.Want = append(f.Want, fixture.ShareFile{
+			ID:    file.ID,
+			Name:  clean(file.GetName()),
+			Size:  file.Size,
+			Time:  file.Time,
+			Url:   clean(file.Url),
+			Pwd:   file.Pwd,
+			IsDir: file.IsFloder,
+		})
+	}
+	if err := f.Save(out); err != nil {
+		return err
+	}
+	fmt.Printf("recorded %d requests, %d files to %s\n", len(f.Exchanges), len(f.Want), strings.TrimSuffix(out, "/"))
+	return nil
+}